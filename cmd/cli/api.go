@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// apiGet and apiPost are http.Get and http.Post with the saved bearer token attached.
+func apiGet(u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiDo(req)
+}
+
+func apiPost(u, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return apiDo(req)
+}
+
+func apiDo(req *http.Request) (*http.Response, error) {
+	if token := loadConfig().Token; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		fmt.Println("🔒 Not logged in or session expired — use Switch Profile / Login (Option 10)")
+	}
+	return resp, err
+}
+
+// apiFailed prints the error a service returned, if the response is not a 2xx, and
+// reports whether it did.
+func apiFailed(resp *http.Response) bool {
+	if resp.StatusCode < 300 {
+		return false
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) != nil || body.Error == "" {
+		body.Error = resp.Status
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		fmt.Println("❌", body.Error)
+	}
+	return true
+}
+
+// login exchanges a user id and password for a token and saves both as the active session.
+func login(userID int, password string) error {
+	body, _ := json.Marshal(map[string]interface{}{"user_id": userID, "password": password})
+	resp, err := http.Post(userAPI+"/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("login failed: %s", resp.Status)
+	}
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return err
+	}
+	cfg := loadConfig()
+	cfg.Token = data.Token
+	if userID != 0 {
+		cfg.ActiveUserID = userID
+	}
+	return saveConfig(cfg)
+}
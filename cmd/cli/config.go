@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cliConfig is the CLI state persisted between runs.
+type cliConfig struct {
+	ActiveUserID int    `json:"active_user_id,omitempty"`
+	Token        string `json:"token,omitempty"`
+}
+
+// configPath returns CLI_CONFIG, or rent-cost-analyzer/cli.json under the user config dir.
+func configPath() string {
+	if p := os.Getenv("CLI_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "rent-cost-analyzer", "cli.json")
+}
+
+// loadConfig returns the saved config, or an empty one if none has been saved yet.
+func loadConfig() cliConfig {
+	var cfg cliConfig
+	b, err := os.ReadFile(configPath())
+	if err != nil {
+		return cfg
+	}
+	json.Unmarshal(b, &cfg)
+	return cfg
+}
+
+func saveConfig(cfg cliConfig) error {
+	path := configPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importTargets maps the dataset names accepted by `cli import` to their service's endpoint.
+var importTargets = map[string]string{
+	"listings":  rentalAPI + "/import",
+	"groceries": groceryAPI + "/import",
+	"routes":    transportAPI + "/import",
+	"inflation": inflationAPI + "/import",
+}
+
+// runImport implements `cli import [-dry-run] <dataset> <file>` and returns the exit code.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate and roll back instead of importing")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cli import [-dry-run] <listings|groceries|routes|inflation> <file.csv|file.ndjson>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	dataset, path := fs.Arg(0), fs.Arg(1)
+
+	endpoint, ok := importTargets[dataset]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "❌ Unknown dataset %q\n", dataset)
+		fs.Usage()
+		return 2
+	}
+	var contentType string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		contentType = "text/csv"
+	case ".ndjson", ".jsonl":
+		contentType = "application/x-ndjson"
+	default:
+		fmt.Fprintln(os.Stderr, "❌ File must end in .csv, .ndjson or .jsonl")
+		return 2
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ Error:", err)
+		return 1
+	}
+	defer f.Close()
+
+	if *dryRun {
+		endpoint += "?dry_run=true"
+	}
+	resp, err := apiPost(endpoint, contentType, f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ Error:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Rows     int  `json:"rows"`
+		Inserted int  `json:"inserted"`
+		DryRun   bool `json:"dry_run"`
+		Errors   []struct {
+			Row     int    `json:"row"`
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusUnprocessableEntity:
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			fmt.Fprintln(os.Stderr, "❌ Error:", err)
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "❌ Import failed:", resp.Status)
+		return 1
+	}
+
+	if len(res.Errors) > 0 {
+		fmt.Printf("❌ %d problem(s) in %d row(s); nothing was imported:\n", len(res.Errors), res.Rows)
+		for _, e := range res.Errors {
+			field := e.Field
+			if field == "" {
+				field = "-"
+			}
+			fmt.Printf("   line %-5d %-18s %s\n", e.Row, field, e.Message)
+		}
+		return 1
+	}
+	if res.DryRun {
+		fmt.Printf("✅ Dry run: all %d row(s) of %s are valid; nothing was written\n", res.Rows, dataset)
+	} else {
+		fmt.Printf("✅ Imported %d %s row(s)\n", res.Inserted, dataset)
+	}
+	return 0
+}
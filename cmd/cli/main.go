@@ -5,35 +5,43 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/pkg/models"
 )
 
-const defaultHost = "localhost"
+const defaultServicesURL = "http://localhost:8080"
 
-func baseURL(port int) string {
-	host := os.Getenv("SERVICES_HOST")
-	if host == "" {
-		host = defaultHost
+// servicesURL is the gateway every service is reached through (SERVICES_URL env or default).
+func servicesURL() string {
+	if u := os.Getenv("SERVICES_URL"); u != "" {
+		return strings.TrimRight(u, "/")
 	}
-	return fmt.Sprintf("http://%s:%d", host, port)
+	return defaultServicesURL
 }
 
 var (
-	userAPI    = baseURL(8081)
-	rentalAPI  = baseURL(8082)
-	groceryAPI = baseURL(8083)
-	transportAPI = baseURL(8084)
-	inflationAPI = baseURL(8085)
-	geospatialAPI = baseURL(8086)
-	predictionAPI = baseURL(8087)
+	userAPI       = servicesURL() + "/api/user"
+	rentalAPI     = servicesURL() + "/api/rental"
+	groceryAPI    = servicesURL() + "/api/grocery"
+	transportAPI  = servicesURL() + "/api/transport"
+	inflationAPI  = servicesURL() + "/api/inflation"
+	geospatialAPI = servicesURL() + "/api/geospatial"
+	predictionAPI = servicesURL() + "/api/prediction"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║    RENT & COST ANALYZER - Ashta, Madhya Pradesh, IN      ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
@@ -56,6 +64,7 @@ func main() {
 			calculateTransportCosts()
 		case "6":
 			showInflationData()
+			showInflationForecast()
 		case "7":
 			geospatialAnalysis()
 		case "8":
@@ -63,6 +72,8 @@ func main() {
 		case "9":
 			showCostBurdenIndex()
 		case "10":
+			switchProfile()
+		case "11":
 			fmt.Println("\n👋 Thank you for using Rent & Cost Analyzer!")
 			return
 		default:
@@ -94,7 +105,8 @@ func showMainMenu() {
 	fmt.Println("║  7. 🗺️  Geospatial Analysis (PostGIS)                     ║")
 	fmt.Println("║  8. 📍 Compare Localities                                 ║")
 	fmt.Println("║  9. 💰 Cost Burden Index                                  ║")
-	fmt.Println("║ 10. 🚪 Exit                                               ║")
+	fmt.Println("║ 10. 👥 Switch Profile / Login                             ║")
+	fmt.Println("║ 11. 🚪 Exit                                               ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 }
 
@@ -111,6 +123,8 @@ func createUserProfile() {
 	preferredLocale := getUserInput("Preferred locality: ")
 	distStr := getUserInput("Commute distance to work (km): ")
 	commuteDistance, _ := strconv.ParseFloat(distStr, 64)
+	workplace := getUserInput("Workplace locality (optional): ")
+	password := getUserInput("Choose a password (min 8 characters): ")
 
 	body, _ := json.Marshal(map[string]interface{}{
 		"name":               name,
@@ -118,8 +132,10 @@ func createUserProfile() {
 		"family_size":        familySize,
 		"preferred_locale":   preferredLocale,
 		"commute_distance":   commuteDistance,
+		"workplace":          workplace,
+		"password":           password,
 	})
-	resp, err := http.Post(userAPI+"/profile", "application/json", bytes.NewReader(body))
+	resp, err := apiPost(userAPI+"/users", "application/json", bytes.NewReader(body))
 	if err != nil {
 		fmt.Println("❌ Error calling user service:", err)
 		return
@@ -131,18 +147,111 @@ func createUserProfile() {
 		return
 	}
 
-	fmt.Printf("\n✅ Profile created successfully for %s!\n", name)
+	var created struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	if err := login(created.ID, password); err != nil {
+		fmt.Println("⚠️  Profile created but login failed:", err)
+		return
+	}
+
+	fmt.Printf("\n✅ Profile #%d created successfully for %s and logged in!\n", created.ID, name)
 	fmt.Printf("   Income: ₹%.2f | Family: %d | Preferred: %s | Commute: %.1fkm\n",
 		income, familySize, preferredLocale, commuteDistance)
 }
 
+// getActiveProfile fetches the profile selected in the CLI config. With no profile
+// selected it requests id 0, which the user service answers with 404.
+func getActiveProfile() (*http.Response, error) {
+	return apiGet(fmt.Sprintf("%s/users/%d", userAPI, loadConfig().ActiveUserID))
+}
+
+func switchProfile() {
+	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
+	fmt.Println("║                SWITCH PROFILE / LOGIN                     ║")
+	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
+
+	resp, err := apiGet(userAPI + "/users?limit=100")
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		loginPrompt()
+		return
+	}
+
+	var data struct {
+		Users []struct {
+			ID              int     `json:"id"`
+			Name            string  `json:"name"`
+			Income          float64 `json:"income"`
+			FamilySize      int     `json:"family_size"`
+			PreferredLocale string  `json:"preferred_locale"`
+		} `json:"users"`
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	if len(data.Users) == 0 {
+		fmt.Println("\n❌ No profiles yet. Create one first (Option 1)")
+		return
+	}
+
+	active := loadConfig().ActiveUserID
+	fmt.Println("\n┌──────┬──────────────────────┬─────────────┬────────┬────────────────────┐")
+	fmt.Println("│  ID  │        Name          │   Income    │ Family │     Preferred      │")
+	fmt.Println("├──────┼──────────────────────┼─────────────┼────────┼────────────────────┤")
+	for _, u := range data.Users {
+		marker := " "
+		if u.ID == active {
+			marker = "*"
+		}
+		fmt.Printf("│%s%4d │ %-20s │ ₹%10.2f │   %2d   │ %-18s │\n",
+			marker, u.ID, u.Name, u.Income, u.FamilySize, u.PreferredLocale)
+	}
+	fmt.Println("└──────┴──────────────────────┴─────────────┴────────┴────────────────────┘")
+	if data.Total > len(data.Users) {
+		fmt.Printf("   (showing %d of %d profiles)\n", len(data.Users), data.Total)
+	}
+
+	loginPrompt()
+}
+
+// loginPrompt asks for a profile id and password and logs in as that profile.
+// Id 0 is the admin account.
+func loginPrompt() {
+	id, err := strconv.Atoi(getUserInput("\nEnter profile ID to log in as (0 = admin): "))
+	if err != nil || id < 0 {
+		fmt.Println("❌ Invalid ID")
+		return
+	}
+	password := getUserInput("Password: ")
+	if err := login(id, password); err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if id == 0 {
+		fmt.Println("\n✅ Logged in as admin")
+		return
+	}
+	fmt.Printf("\n✅ Logged in; profile #%d is now active\n", id)
+}
+
 func analyzeRentListings() {
 	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
 	fmt.Println("║        RENT LISTINGS - AI NLP CLASSIFICATION              ║")
 	fmt.Println("║           (PyTorch DistilBERT Model)                      ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 
-	resp, err := http.Get(rentalAPI + "/listings")
+	resp, err := apiGet(rentalAPI + "/listings")
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
@@ -173,28 +282,33 @@ func analyzeRentListings() {
 		classIcon := "✅"
 		if l.Classification == "overpriced" {
 			classIcon = "⚠️ "
+		} else if l.Classification == "underpriced" {
+			classIcon = "💎"
 		}
 		fmt.Printf("│ %4d │ %-15s │ ₹%7.0f │ %2d │ %4d │ %s %-10s │ %.1fkm  │\n",
 			l.ID, l.Locality, l.Rent, l.Bedrooms, l.Sqft, classIcon, l.Classification, l.Distance)
 	}
 	fmt.Println("└──────┴─────────────────┴─────────┴────┴──────┴──────────────┴──────────┘")
 
-	sumResp, _ := http.Get(rentalAPI + "/listings/summary")
+	sumResp, _ := apiGet(rentalAPI + "/listings/summary")
 	if sumResp != nil {
 		defer sumResp.Body.Close()
 		var sum struct {
-			Fair       int `json:"fair"`
-			Overpriced int `json:"overpriced"`
+			Fair        int `json:"fair"`
+			Overpriced  int `json:"overpriced"`
+			Underpriced int `json:"underpriced"`
 		}
 		if json.NewDecoder(sumResp.Body).Decode(&sum) == nil {
-			fmt.Printf("\n📊 Classification Summary: %d Fair listings | %d Overpriced listings\n", sum.Fair, sum.Overpriced)
+			fmt.Printf("\n📊 Classification Summary: %d Fair listings | %d Overpriced listings | %d Underpriced listings\n",
+				sum.Fair, sum.Overpriced, sum.Underpriced)
+			fmt.Println("💡 Listings are priced against comparables (same locality, bedrooms, ±25% sqft); see /listings/{id}/valuation")
 		}
 	}
 }
 
 func predictMonthlyCosts() {
 	// Get user profile first
-	resp, err := http.Get(userAPI + "/profile")
+	resp, err := getActiveProfile()
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
@@ -226,7 +340,7 @@ func predictMonthlyCosts() {
 	fmt.Println("\n🤖 Running XGBoost model with your profile...")
 
 	body, _ := json.Marshal(user)
-	preResp, err := http.Post(predictionAPI+"/predict", "application/json", bytes.NewReader(body))
+	preResp, err := apiPost(predictionAPI+"/predict", "application/json", bytes.NewReader(body))
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
@@ -239,8 +353,12 @@ func predictMonthlyCosts() {
 		Transport   float64 `json:"transport"`
 		Total       float64 `json:"total"`
 		CostBurden  float64 `json:"cost_burden"`
-		Confidence  float64 `json:"confidence"`
-		FeatureImp  map[string]string `json:"feature_importance"`
+		Intervals   map[string]struct {
+			P10 float64 `json:"p10"`
+			P50 float64 `json:"p50"`
+			P90 float64 `json:"p90"`
+		} `json:"intervals"`
+		FeatureImp  map[string]map[string]float64 `json:"feature_importance"`
 	}
 	if err := json.NewDecoder(preResp.Body).Decode(&pred); err != nil {
 		fmt.Println("❌ Error:", err)
@@ -265,54 +383,128 @@ func predictMonthlyCosts() {
 		fmt.Println("\n✅ Cost burden is within acceptable range")
 	}
 
-	fmt.Printf("\n📈 Model Confidence: %.1f%%\n", pred.Confidence)
-	fmt.Println("📝 Feature Importance: Rent (45%), Groceries (32%), Transport (23%)")
+	fmt.Println("\n📈 Prediction Intervals (80%: P10 – P90, median P50):")
+	for _, component := range []string{"rent", "groceries", "transport", "total"} {
+		iv, ok := pred.Intervals[component]
+		if !ok {
+			fmt.Printf("   • %-10s no interval (training costs don't vary enough)\n", component+":")
+			continue
+		}
+		fmt.Printf("   • %-10s ₹%8.2f – ₹%8.2f  (P50 ₹%.2f)\n", component+":", iv.P10, iv.P90, iv.P50)
+	}
+	fmt.Println("📝 Feature Importance (share of split gain):")
+	for _, component := range []string{"rent", "groceries", "transport"} {
+		imp := pred.FeatureImp[component]
+		names := make([]string, 0, len(imp))
+		for name := range imp {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return imp[names[i]] > imp[names[j]] })
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s (%.0f%%)", name, imp[name]*100))
+		}
+		fmt.Printf("   • %-10s %s\n", component+":", strings.Join(parts, ", "))
+	}
 }
 
 func showGroceryPricing() {
 	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
 	fmt.Println("║          GROCERY PRICING ANALYSIS                         ║")
-	fmt.Println("║         (BigBasket & Blinkit Integration)                 ║")
+	fmt.Println("║       (BigBasket, Blinkit & Local Mandi prices)           ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 
-	resp, err := http.Get(groceryAPI + "/items")
+	household := fmt.Sprintf("user_id=%d", loadConfig().ActiveUserID)
+	if loadConfig().ActiveUserID == 0 {
+		size := getUserInput("No active profile. Family size: ")
+		household = "family_size=" + url.QueryEscape(size)
+	}
+	resp, err := apiGet(groceryAPI + "/basket?" + household)
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Println("❌ Failed to price basket:", resp.Status)
+		return
+	}
 
-	var data struct {
-		Items           []struct { Item string `json:"item"`; Price float64 `json:"price"`; Source string `json:"source"` } `json:"items"`
-		MonthlyEstimate float64 `json:"monthly_estimate"`
+	var basket models.GroceryBasket
+	if err := json.NewDecoder(resp.Body).Decode(&basket); err != nil {
+		fmt.Println("❌ Error:", err)
+		return
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+
+	fmt.Printf("\n🛒 Monthly basket for %d adult(s), %d child(ren)\n", basket.Adults, basket.Children)
+	fmt.Println("\n┌────────────────────────────┬───────────┬─────────────┬────────────┬────────────┐")
+	fmt.Println("│          Item              │ Unit price│  Qty/month  │  Monthly   │   Source   │")
+	fmt.Println("├────────────────────────────┼───────────┼─────────────┼────────────┼────────────┤")
+
+	for _, it := range basket.Items {
+		qty := fmt.Sprintf("%.2f %s", it.MonthlyQty, it.Unit)
+		fmt.Printf("│ %-26s │ ₹%8.2f │ %-11s │ ₹%9.2f │ %-10s │\n", it.Item, it.Price, qty, it.MonthlyCost, it.Source)
+	}
+
+	fmt.Println("├────────────────────────────┼───────────┼─────────────┼────────────┼────────────┤")
+	fmt.Printf("│ ESTIMATED MONTHLY TOTAL    │           │             │ ₹%9.2f │            │\n", basket.MonthlyTotal)
+	fmt.Println("└────────────────────────────┴───────────┴─────────────┴────────────┴────────────┘")
+
+	if n := basket.Adults + basket.Children; n > 0 {
+		fmt.Printf("\n📊 Per person: ₹%.2f/month (weekly basket ₹%.2f)\n", basket.MonthlyTotal/float64(n), basket.WeeklyTotal)
+	}
+
+	showGrocerySavings(household)
+}
+
+// showGrocerySavings compares the household's basket across vendors and shows what
+// switching would save.
+func showGrocerySavings(household string) {
+	resp, err := apiGet(groceryAPI + "/compare?" + household)
+	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Println("❌ Failed to compare vendors:", resp.Status)
+		return
+	}
 
-	fmt.Println("\n┌────────────────────────────┬───────────┬────────────┐")
-	fmt.Println("│          Item              │   Price   │   Source   │")
-	fmt.Println("├────────────────────────────┼───────────┼────────────┤")
+	var cmp models.PriceComparison
+	if err := json.NewDecoder(resp.Body).Decode(&cmp); err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
 
-	var total float64
-	for _, it := range data.Items {
-		fmt.Printf("│ %-26s │ ₹%8.2f │ %-10s │\n", it.Item, it.Price, it.Source)
-		total += it.Price
+	fmt.Println("\n🏪 VENDOR COMPARISON (monthly basket)")
+	for _, v := range cmp.SingleVendor {
+		note := ""
+		if !v.Complete {
+			note = fmt.Sprintf("  (missing: %s)", strings.Join(v.Missing, ", "))
+		}
+		fmt.Printf("   %-14s ₹%9.2f%s\n", v.Source, v.MonthlyTotal, note)
 	}
+	fmt.Printf("   %-14s ₹%9.2f  (cheapest source per item)\n", "Split basket", cmp.SplitVendor.MonthlyTotal)
 
-	fmt.Println("├────────────────────────────┼───────────┼────────────┤")
-	fmt.Printf("│ ESTIMATED MONTHLY TOTAL    │ ₹%8.2f │            │\n", data.MonthlyEstimate)
-	fmt.Println("└────────────────────────────┴───────────┴────────────┘")
+	fmt.Println("\n🧾 Cheapest source per item:")
+	for _, it := range cmp.Items {
+		fmt.Printf("   %-26s %-12s ₹%.2f/%s\n", it.Item, it.CheapestSource, it.CheapestPrice, it.Unit)
+	}
 
-	if len(data.Items) > 0 {
-		fmt.Printf("\n📊 Average item price: ₹%.2f\n", total/float64(len(data.Items)))
+	fmt.Printf("\n💰 Current basket: ₹%.2f/month\n", cmp.CurrentTotal)
+	if cmp.CheapestVendor != "" && cmp.Savings.SingleVendor > 0 {
+		fmt.Printf("   Switch everything to %s: save ₹%.2f/month\n", cmp.CheapestVendor, cmp.Savings.SingleVendor)
+	}
+	if cmp.Savings.SplitVendor > 0 {
+		fmt.Printf("   Split across vendors: save ₹%.2f/month (₹%.2f/year)\n", cmp.Savings.SplitVendor, cmp.Savings.SplitVendor*12)
+	} else {
+		fmt.Println("   You're already buying at the cheapest prices.")
 	}
-	fmt.Println("💡 Tip: BigBasket tends to be cheaper for staples, Blinkit for quick delivery")
 }
 
 func calculateTransportCosts() {
-	resp, err := http.Get(userAPI + "/profile")
+	resp, err := getActiveProfile()
 	if err != nil || resp.StatusCode == http.StatusNotFound {
 		fmt.Println("\n❌ Please create a user profile first (Option 1)")
 		if resp != nil {
@@ -335,12 +527,15 @@ func calculateTransportCosts() {
 	destination := getUserInput("\nEnter work/destination locality: ")
 
 	routeURL := fmt.Sprintf("%s/route?from=%s&to=%s", transportAPI, url.QueryEscape(user.PreferredLocale), url.QueryEscape(destination))
-	routeResp, err := http.Get(routeURL)
+	routeResp, err := apiGet(routeURL)
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
 	defer routeResp.Body.Close()
+	if apiFailed(routeResp) {
+		return
+	}
 
 	var routeData struct {
 		Found       bool    `json:"found"`
@@ -350,19 +545,29 @@ func calculateTransportCosts() {
 			Distance float64 `json:"distance"`
 			Fare     float64 `json:"fare"`
 		} `json:"route"`
-		DailyCost   float64 `json:"daily_cost"`
-		MonthlyCost float64 `json:"monthly_cost"`
+		Legs []struct {
+			FromLocality string  `json:"from_locality"`
+			ToLocality   string  `json:"to_locality"`
+			Distance     float64 `json:"distance"`
+			Fare         float64 `json:"fare"`
+		} `json:"legs"`
+		TotalTimeMin float64 `json:"total_time_min"`
+		DailyCost    float64 `json:"daily_cost"`
+		MonthlyCost  float64 `json:"monthly_cost"`
+		Modes        []fare.Quote `json:"modes"`
+		Cheapest     fare.Mode    `json:"cheapest"`
+		Fastest      fare.Mode    `json:"fastest"`
 	}
 	json.NewDecoder(routeResp.Body).Decode(&routeData)
 
 	distance := user.CommuteDistance
-	fare := distance * 8
-	dailyCost := fare * 2
-	monthlyCost := dailyCost * 26
+	oneWay := fare.BusFare(distance)
+	dailyCost := oneWay * 2
+	monthlyCost := dailyCost * fare.WorkingDays
 
 	if routeData.Found {
 		distance = routeData.Route.Distance
-		fare = routeData.Route.Fare
+		oneWay = routeData.Route.Fare
 		dailyCost = routeData.DailyCost
 		monthlyCost = routeData.MonthlyCost
 	}
@@ -371,11 +576,36 @@ func calculateTransportCosts() {
 	fmt.Printf("│ Route: %-48s │\n", user.PreferredLocale+" → "+destination)
 	fmt.Println("├─────────────────────────────────────────────────────────┤")
 	fmt.Printf("│ Distance (one way):      %.1f km                         │\n", distance)
-	fmt.Printf("│ Fare (one way):          ₹%.2f                           │\n", fare)
+	fmt.Printf("│ Fare (one way):          ₹%.2f                           │\n", oneWay)
 	fmt.Printf("│ Daily Cost (round trip): ₹%.2f                          │\n", dailyCost)
 	fmt.Printf("│ Monthly Cost (26 days):  ₹%.2f                         │\n", monthlyCost)
 	fmt.Println("└─────────────────────────────────────────────────────────┘")
 
+	if len(routeData.Legs) > 1 {
+		fmt.Printf("\n🔁 %d legs, about %.0f min:\n", len(routeData.Legs), routeData.TotalTimeMin)
+		for i, l := range routeData.Legs {
+			fmt.Printf("   %d. %s → %s  %.1f km  ₹%.2f\n", i+1, l.FromLocality, l.ToLocality, l.Distance, l.Fare)
+		}
+	}
+
+	if len(routeData.Modes) > 0 {
+		fmt.Println("\n🛺 By mode (one way / monthly):")
+		for _, q := range routeData.Modes {
+			note := ""
+			switch {
+			case !q.Practical:
+				note = " (too far to walk)"
+			case q.Mode == routeData.Cheapest && q.Mode == routeData.Fastest:
+				note = " ← cheapest, fastest"
+			case q.Mode == routeData.Cheapest:
+				note = " ← cheapest"
+			case q.Mode == routeData.Fastest:
+				note = " ← fastest"
+			}
+			fmt.Printf("   • %-12s ₹%7.2f  %5.0f min  ₹%8.2f/month%s\n", q.Mode, q.Fare, q.TimeMin, q.MonthlyCost, note)
+		}
+	}
+
 	fmt.Println("\n🚌 BCLL Bus Pass Options:")
 	fmt.Printf("   • Weekly Pass:  ₹%.2f (saves %.0f%%)\n", monthlyCost*0.7/4, 30.0)
 	fmt.Printf("   • Monthly Pass: ₹%.2f (saves %.0f%%)\n", monthlyCost*0.6, 40.0)
@@ -387,7 +617,7 @@ func showInflationData() {
 	fmt.Println("║         (RBI & MP Government Sources)                     ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 
-	resp, err := http.Get(inflationAPI + "/data")
+	resp, err := apiGet(inflationAPI + "/data")
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
@@ -421,7 +651,7 @@ func showInflationData() {
 	}
 	fmt.Println("└──────────────┴──────────────┴────────────┘")
 
-	sumResp, _ := http.Get(inflationAPI + "/summary")
+	sumResp, _ := apiGet(inflationAPI + "/summary")
 	if sumResp != nil {
 		defer sumResp.Body.Close()
 		var sum struct {
@@ -433,6 +663,80 @@ func showInflationData() {
 			fmt.Println("📈 Trend:", sum.Trend)
 		}
 	}
+
+	anResp, _ := apiGet(inflationAPI + "/analytics")
+	if anResp == nil {
+		return
+	}
+	defer anResp.Body.Close()
+	var an struct {
+		Categories []struct {
+			Category   string   `json:"category"`
+			Latest     float64  `json:"latest"`
+			MoM        *float64 `json:"mom_change"`
+			YoY        *float64 `json:"yoy_change"`
+			RollingAvg float64  `json:"rolling_avg"`
+			Direction  string   `json:"direction"`
+		} `json:"categories"`
+	}
+	if json.NewDecoder(anResp.Body).Decode(&an) != nil || len(an.Categories) == 0 {
+		return
+	}
+	change := func(v *float64) string {
+		if v == nil {
+			return "     –"
+		}
+		return fmt.Sprintf("%+6.2f", *v)
+	}
+	fmt.Println("\n📉 By category (changes in percentage points, 3-month rolling average):")
+	fmt.Printf("   %-12s %7s %7s %7s %8s  %s\n", "Category", "Latest", "MoM", "YoY", "Rolling", "Trend")
+	for _, c := range an.Categories {
+		fmt.Printf("   %-12s %6.2f%% %7s %7s %7.2f%%  %s\n", c.Category, c.Latest, change(c.MoM), change(c.YoY), c.RollingAvg, c.Direction)
+	}
+}
+
+// showInflationForecast prints the Overall rate forecast for the next six months with its
+// 80% band, and how far off the model was on the last months it was backtested on.
+func showInflationForecast() {
+	resp, err := apiGet(inflationAPI + "/forecast?category=Overall&months=6")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var fc struct {
+		Forecasts []struct {
+			Forecast []struct {
+				Month   string  `json:"month"`
+				Rate    float64 `json:"rate"`
+				Lower80 float64 `json:"lower_80"`
+				Upper80 float64 `json:"upper_80"`
+			} `json:"forecast"`
+		} `json:"forecasts"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&fc) != nil || len(fc.Forecasts) == 0 {
+		return
+	}
+	fmt.Println("\n🔮 Overall inflation forecast (80% band):")
+	for _, p := range fc.Forecasts[0].Forecast {
+		fmt.Printf("   %-9s %5.2f%%  (%.2f%% – %.2f%%)\n", p.Month, p.Rate, p.Lower80, p.Upper80)
+	}
+
+	btResp, err := apiGet(inflationAPI + "/backtest?category=Overall")
+	if err != nil {
+		return
+	}
+	defer btResp.Body.Close()
+	var bt struct {
+		Results []struct {
+			Months    int     `json:"months"`
+			MAPE      float64 `json:"mape"`
+			NaiveMAPE float64 `json:"naive_mape"`
+		} `json:"results"`
+	}
+	if json.NewDecoder(btResp.Body).Decode(&bt) == nil && len(bt.Results) > 0 {
+		r := bt.Results[0]
+		fmt.Printf("   Backtest on the last %d month(s): MAPE %.2f%% (last-value baseline %.2f%%)\n", r.Months, r.MAPE, r.NaiveMAPE)
+	}
 }
 
 func geospatialAnalysis() {
@@ -448,7 +752,7 @@ func geospatialAnalysis() {
 
 	switch choice {
 	case "1":
-		resp, err := http.Get(geospatialAPI + "/heatmap")
+		resp, err := apiGet(geospatialAPI + "/heatmap")
 		if err != nil {
 			fmt.Println("❌ Error:", err)
 			return
@@ -477,7 +781,7 @@ func geospatialAnalysis() {
 		fmt.Println("└────────────────────┴─────────────┴────────┘")
 
 	case "2":
-		resp, err := http.Get(userAPI + "/profile")
+		resp, err := getActiveProfile()
 		if err != nil || resp.StatusCode == http.StatusNotFound {
 			fmt.Println("\n❌ Please create a user profile first")
 			if resp != nil {
@@ -492,18 +796,22 @@ func geospatialAnalysis() {
 		resp.Body.Close()
 
 		fmt.Println("\n🕐 ISOCHRONE ANALYSIS - Travel Time Zones")
-		fmt.Printf("   From: %s\n\n", user.PreferredLocale)
+		fmt.Printf("   From: %s\n", user.PreferredLocale)
 
-		isoURL := fmt.Sprintf("%s/isochrone?from=%s", transportAPI, url.QueryEscape(user.PreferredLocale))
-		isoResp, err := http.Get(isoURL)
+		isoURL := fmt.Sprintf("%s/isochrone?from=%s&period=%s", transportAPI, url.QueryEscape(user.PreferredLocale), time.Now().Format("15:04"))
+		isoResp, err := apiGet(isoURL)
 		if err != nil {
 			fmt.Println("❌ Error:", err)
 			return
 		}
 		defer isoResp.Body.Close()
+		if apiFailed(isoResp) {
+			return
+		}
 
 		var iso struct {
 			From         string `json:"from"`
+			Period       string `json:"period"`
 			Destinations []struct {
 				ToLocality string  `json:"to_locality"`
 				Distance   float64 `json:"distance_km"`
@@ -514,6 +822,7 @@ func geospatialAnalysis() {
 		if json.NewDecoder(isoResp.Body).Decode(&iso) != nil {
 			return
 		}
+		fmt.Printf("   By bus, %s traffic\n\n", strings.ReplaceAll(iso.Period, "_", "-"))
 		fmt.Println("┌────────────────────┬──────────┬──────────┬──────────────┐")
 		fmt.Println("│   Destination      │ Distance │   Fare   │  Time Zone   │")
 		fmt.Println("├────────────────────┼──────────┼──────────┼──────────────┤")
@@ -521,6 +830,8 @@ func geospatialAnalysis() {
 			color := "🟢"
 			if d.Zone == "45+ min" {
 				color = "🔴"
+			} else if d.Zone == "45 min" {
+				color = "🟠"
 			} else if d.Zone == "30 min" {
 				color = "🟡"
 			}
@@ -532,33 +843,38 @@ func geospatialAnalysis() {
 		locality := getUserInput("\nEnter locality to search near: ")
 		fmt.Printf("\n📍 Searching localities within 5km radius of %s...\n", locality)
 
-		nearURL := fmt.Sprintf("%s/nearby?locality=%s", geospatialAPI, url.QueryEscape(locality))
-		nearResp, err := http.Get(nearURL)
+		nearURL := fmt.Sprintf("%s/nearby?radius_km=5&locality=%s", geospatialAPI, url.QueryEscape(locality))
+		nearResp, err := apiGet(nearURL)
 		if err != nil {
 			fmt.Println("❌ Error:", err)
 			return
 		}
 		defer nearResp.Body.Close()
+		if apiFailed(nearResp) {
+			return
+		}
 
 		var near struct {
-			Center string `json:"center"`
 			Nearby []struct {
-				Locality string  `json:"locality"`
-				Distance float64 `json:"distance_km"`
-				Lat      float64 `json:"lat"`
-				Lon      float64 `json:"lon"`
+				Locality     string  `json:"locality"`
+				Distance     float64 `json:"distance_km"`
+				AvgRent      float64 `json:"avg_rent"`
+				ListingCount int     `json:"listing_count"`
 			} `json:"nearby"`
 		}
 		if json.NewDecoder(nearResp.Body).Decode(&near) != nil {
 			return
 		}
-		fmt.Println("\n┌────────────────────┬──────────┬─────────────────────────┐")
-		fmt.Println("│   Nearby Locality  │ Distance │      Coordinates        │")
-		fmt.Println("├────────────────────┼──────────┼─────────────────────────┤")
+		fmt.Println("\n┌────────────────────┬──────────┬─────────────┬──────────┐")
+		fmt.Println("│   Nearby Locality  │ Distance │  Avg Rent   │ Listings │")
+		fmt.Println("├────────────────────┼──────────┼─────────────┼──────────┤")
 		for _, n := range near.Nearby {
-			fmt.Printf("│ %-18s │ %6.1fkm │ %.4f°N, %.4f°E │\n", n.Locality, n.Distance, n.Lat, n.Lon)
+			fmt.Printf("│ %-18s │ %6.1fkm │ ₹%10.2f │ %8d │\n", n.Locality, n.Distance, n.AvgRent, n.ListingCount)
 		}
-		fmt.Println("└────────────────────┴──────────┴─────────────────────────┘")
+		if len(near.Nearby) == 0 {
+			fmt.Printf("│ %-53s │\n", "No other localities have listings within 5 km")
+		}
+		fmt.Println("└────────────────────┴──────────┴─────────────┴──────────┘")
 
 	default:
 		fmt.Println("❌ Invalid choice")
@@ -570,64 +886,90 @@ func compareLocalities() {
 	fmt.Println("║              LOCALITY COMPARISON TOOL                     ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 
-	loc1 := getUserInput("\nEnter first locality: ")
-	loc2 := getUserInput("Enter second locality: ")
+	locs := getUserInput("\nEnter localities to compare (comma-separated, 2-10): ")
+	params := url.Values{"localities": {locs}}
+	if id := loadConfig().ActiveUserID; id != 0 {
+		params.Set("user_id", strconv.Itoa(id))
+	}
+	if workplace := getUserInput("Workplace locality (blank for your profile's): "); workplace != "" {
+		params.Set("workplace", workplace)
+	}
 
-	compareURL := fmt.Sprintf("%s/compare?loc1=%s&loc2=%s", rentalAPI, url.QueryEscape(loc1), url.QueryEscape(loc2))
-	resp, err := http.Get(compareURL)
+	resp, err := apiGet(rentalAPI + "/compare?" + params.Encode())
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
 	defer resp.Body.Close()
+	if apiFailed(resp) {
+		return
+	}
 
 	var data struct {
-		Locality1 string `json:"locality1"`
-		Locality2 string `json:"locality2"`
-		Analysis1 struct {
-			Rent      float64 `json:"rent"`
-			Groceries float64 `json:"groceries"`
-			Transport float64 `json:"transport"`
-			Total     float64 `json:"total"`
-		} `json:"analysis1"`
-		Analysis2 struct {
-			Rent      float64 `json:"rent"`
-			Groceries float64 `json:"groceries"`
-			Transport float64 `json:"transport"`
-			Total     float64 `json:"total"`
-		} `json:"analysis2"`
+		Context struct {
+			FamilySize int    `json:"family_size"`
+			Workplace  string `json:"workplace"`
+		} `json:"context"`
+		Localities []struct {
+			Rank     int    `json:"rank"`
+			Locality string `json:"locality"`
+			Analysis struct {
+				Rent      float64 `json:"rent"`
+				Groceries float64 `json:"groceries"`
+				Transport float64 `json:"transport"`
+				Total     float64 `json:"total"`
+			} `json:"analysis"`
+			Commute *struct {
+				Mode string `json:"mode"`
+			} `json:"commute"`
+			Delta struct {
+				Total float64 `json:"total"`
+			} `json:"delta"`
+			Complete bool     `json:"complete"`
+			Missing  []string `json:"missing"`
+		} `json:"localities"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
 
-	a1, a2 := data.Analysis1, data.Analysis2
-	fmt.Println("\n┌─────────────────────────┬──────────────────┬──────────────────┐")
-	fmt.Printf("│ Metric                  │ %-16s │ %-16s │\n", data.Locality1, data.Locality2)
-	fmt.Println("├─────────────────────────┼──────────────────┼──────────────────┤")
-	fmt.Printf("│ Avg Rent                │ ₹%14.2f │ ₹%14.2f │\n", a1.Rent, a2.Rent)
-	fmt.Printf("│ Groceries (monthly)     │ ₹%14.2f │ ₹%14.2f │\n", a1.Groceries, a2.Groceries)
-	fmt.Printf("│ Transport (monthly)     │ ₹%14.2f │ ₹%14.2f │\n", a1.Transport, a2.Transport)
-	fmt.Println("├─────────────────────────┼──────────────────┼──────────────────┤")
-	fmt.Printf("│ TOTAL MONTHLY COST      │ ₹%14.2f │ ₹%14.2f │\n", a1.Total, a2.Total)
-	fmt.Println("└─────────────────────────┴──────────────────┴──────────────────┘")
+	workplace := data.Context.Workplace
+	if workplace == "" {
+		workplace = "none (commute not counted)"
+	}
+	fmt.Printf("\n   Family of %d | Workplace: %s\n", data.Context.FamilySize, workplace)
+	fmt.Println("\n┌────┬────────────────────┬───────────┬───────────┬───────────┬────────────┬───────────┐")
+	fmt.Println("│ #  │ Locality           │   Rent    │ Groceries │ Transport │   Total    │  vs best  │")
+	fmt.Println("├────┼────────────────────┼───────────┼───────────┼───────────┼────────────┼───────────┤")
+	for _, l := range data.Localities {
+		a := l.Analysis
+		name := l.Locality
+		if !l.Complete {
+			name += " *"
+		}
+		fmt.Printf("│ %2d │ %-18s │ ₹%8.0f │ ₹%8.0f │ ₹%8.0f │ ₹%9.0f │ +₹%7.0f │\n",
+			l.Rank, name, a.Rent, a.Groceries, a.Transport, a.Total, l.Delta.Total)
+	}
+	fmt.Println("└────┴────────────────────┴───────────┴───────────┴───────────┴────────────┴───────────┘")
 
-	diff := math.Abs(a1.Total - a2.Total)
-	cheaper := data.Locality1
-	if a2.Total < a1.Total {
-		cheaper = data.Locality2
+	for _, l := range data.Localities {
+		if !l.Complete {
+			fmt.Printf("   * %s: no data for %s\n", l.Locality, strings.Join(l.Missing, ", "))
+		}
 	}
-	maxTotal := math.Max(a1.Total, a2.Total)
-	pct := 0.0
-	if maxTotal > 0 {
-		pct = (diff / maxTotal) * 100
+	if len(data.Localities) > 1 && data.Localities[0].Complete {
+		best, last := data.Localities[0], data.Localities[len(data.Localities)-1]
+		fmt.Printf("\n💡 %s is cheapest: ₹%.2f less per month than %s", best.Locality, last.Delta.Total, last.Locality)
+		if best.Commute != nil {
+			fmt.Printf(", commuting by %s", strings.ReplaceAll(best.Commute.Mode, "_", "-"))
+		}
+		fmt.Println()
 	}
-	fmt.Printf("\n💡 %s is ₹%.2f cheaper per month (%.1f%% savings)\n", cheaper, diff, pct)
 }
 
 func showCostBurdenIndex() {
-	resp, err := http.Get(userAPI + "/profile")
+	resp, err := getActiveProfile()
 	if err != nil || resp.StatusCode == http.StatusNotFound {
 		fmt.Println("\n❌ Please create a user profile first (Option 1)")
 		if resp != nil {
@@ -647,13 +989,16 @@ func showCostBurdenIndex() {
 	fmt.Println("║              COST BURDEN INDEX ANALYSIS                   ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
 
-	burdenURL := fmt.Sprintf("%s/cost-burden?income=%.2f", rentalAPI, user.Income)
-	burdenResp, err := http.Get(burdenURL)
+	burdenURL := fmt.Sprintf("%s/cost-burden?user_id=%d&income=%.2f", rentalAPI, loadConfig().ActiveUserID, user.Income)
+	burdenResp, err := apiGet(burdenURL)
 	if err != nil {
 		fmt.Println("❌ Error:", err)
 		return
 	}
 	defer burdenResp.Body.Close()
+	if apiFailed(burdenResp) {
+		return
+	}
 
 	var data struct {
 		Income     float64 `json:"income"`
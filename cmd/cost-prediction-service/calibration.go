@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultCalibrationFolds = 5
+	// maxCalibrationFolds caps folds: every fold trains a full set of models.
+	maxCalibrationFolds = 10
+)
+
+// calibrationScore is how one component's held-out costs fell against its intervals.
+type calibrationScore struct {
+	HeldOut           int     `json:"held_out"`
+	IntervalSupported bool    `json:"interval_supported"`
+	Coverage          float64 `json:"coverage"`
+	BelowP10          float64 `json:"below_p10"`
+	AboveP90          float64 `json:"above_p90"`
+	MeanIntervalWidth float64 `json:"mean_interval_width"`
+	P50MeanAbsError   float64 `json:"p50_mean_abs_error"`
+}
+
+// scoreIntervals compares each actual cost with the interval predicted for it.
+func scoreIntervals(actual []float64, ivs []interval) calibrationScore {
+	var covered, below, above int
+	var width, absErr float64
+	for i, a := range actual {
+		iv := ivs[i]
+		switch {
+		case a < iv.P10:
+			below++
+		case a > iv.P90:
+			above++
+		default:
+			covered++
+		}
+		width += iv.P90 - iv.P10
+		absErr += math.Abs(a - iv.P50)
+	}
+	n := float64(len(actual))
+	return calibrationScore{
+		HeldOut:           len(actual),
+		Coverage:          float64(covered) / n,
+		BelowP10:          float64(below) / n,
+		AboveP90:          float64(above) / n,
+		MeanIntervalWidth: width / n,
+		P50MeanAbsError:   absErr / n,
+	}
+}
+
+// crossValidate splits d into folds by position and predicts every row with models
+// trained on the other folds, returning the intervals in row order.
+func crossValidate(features []string, d dataset, folds int) ([]interval, error) {
+	ivs := make([]interval, len(d.y))
+	for f := 0; f < folds; f++ {
+		var trainSet dataset
+		for i := range d.y {
+			if i%folds != f {
+				trainSet.add(d.X[i], d.y[i])
+			}
+		}
+		comp, err := trainComponent(features, trainSet)
+		if err != nil {
+			return nil, err
+		}
+		for i := f; i < len(d.y); i += folds {
+			_, ivs[i] = comp.predict(d.X[i])
+		}
+	}
+	return ivs, nil
+}
+
+// crossValidateRent is crossValidate for listings. Locality levels are recomputed from
+// each fold's training listings so held-out rents don't leak into their own features.
+func crossValidateRent(listings []listing, folds int) ([]interval, error) {
+	ivs := make([]interval, len(listings))
+	for f := 0; f < folds; f++ {
+		var trainSet []listing
+		for i, l := range listings {
+			if i%folds != f {
+				trainSet = append(trainSet, l)
+			}
+		}
+		td := newTrainingData()
+		td.setRent(trainSet)
+		comp, err := trainComponent(rentFeatures, td.rent)
+		if err != nil {
+			return nil, err
+		}
+		for i := f; i < len(listings); i += folds {
+			_, ivs[i] = comp.predict(td.rentRow(listings[i]))
+		}
+	}
+	return ivs, nil
+}
+
+// handleCalibration serves GET /calibration?folds=N. It cross-validates every component:
+// each one's rows are split into N folds by position, and each fold is predicted by
+// models trained on the other folds. A calibrated P10–P90 interval covers about 80% of
+// held-out costs, with about 10% falling on either side. Components are scored on the
+// interval /predict reports, so one without a supported interval is scored as its
+// median. The total pairs every listing with a grocery and a transport row spread evenly
+// through theirs, and checks the summed actual against the combined interval.
+func handleCalibration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	folds := defaultCalibrationFolds
+	if v := r.URL.Query().Get("folds"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 || n > maxCalibrationFolds {
+			http.Error(w, fmt.Sprintf("folds must be an integer from 2 to %d", maxCalibrationFolds), http.StatusBadRequest)
+			return
+		}
+		folds = n
+	}
+
+	td, err := loadTrainingData(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sets := []struct {
+		name string
+		rows int
+	}{{"listings", len(td.listings)}, {"grocery rows", len(td.groceries.y)}, {"transport rows", len(td.transport.y)}}
+	for _, s := range sets {
+		if s.rows < 2*folds {
+			http.Error(w, fmt.Sprintf("not enough %s for that many folds", s.name), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	rentIvs, err := crossValidateRent(td.listings, folds)
+	if err != nil {
+		http.Error(w, "rent model: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	groceryIvs, err := crossValidate(groceryFeatures, td.groceries, folds)
+	if err != nil {
+		http.Error(w, "groceries model: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	transportIvs, err := crossValidate(transportFeatures, td.transport, folds)
+	if err != nil {
+		http.Error(w, "transport model: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p := &predictor{IntervalSupported: td.intervalSupport()}
+	rentActual := make([]float64, len(td.listings))
+	for i, l := range td.listings {
+		rentActual[i] = l.rent
+		rentIvs[i] = p.served("rent", rentIvs[i])
+	}
+	for i := range groceryIvs {
+		groceryIvs[i] = p.served("groceries", groceryIvs[i])
+	}
+	for i := range transportIvs {
+		transportIvs[i] = p.served("transport", transportIvs[i])
+	}
+
+	n := len(td.listings)
+	totalActual, totalIvs := make([]float64, n), make([]interval, n)
+	for i := range td.listings {
+		g, t := i*len(groceryIvs)/n, i*len(transportIvs)/n
+		totalActual[i] = rentActual[i] + td.groceries.y[g] + td.transport.y[t]
+		totalIvs[i] = sumIntervals(rentIvs[i], groceryIvs[g], transportIvs[t])
+	}
+
+	components := map[string]calibrationScore{
+		"rent":      scoreIntervals(rentActual, rentIvs),
+		"groceries": scoreIntervals(td.groceries.y, groceryIvs),
+		"transport": scoreIntervals(td.transport.y, transportIvs),
+		"total":     scoreIntervals(totalActual, totalIvs),
+	}
+	for name, s := range components {
+		s.IntervalSupported = name == "total" || p.IntervalSupported[name]
+		components[name] = s
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"folds":             folds,
+		"expected_coverage": 0.8,
+		"components":        components,
+	})
+}
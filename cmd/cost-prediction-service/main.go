@@ -1,23 +1,98 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"log"
-	"math/rand"
 	"net/http"
-	"time"
 
+	"rent-cost-analyzer/internal/auth"
+	"rent-cost-analyzer/internal/db"
 	"rent-cost-analyzer/pkg/models"
 )
 
+var conn *sql.DB
+
 func main() {
-	http.HandleFunc("/predict", handlePredict)
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
+	c, err := db.Open()
+	if err != nil {
+		log.Fatal("db open:", err)
+	}
+	defer c.Close()
+	conn = c
+
+	if p, err := loadPredictor(modelPath()); err == nil {
+		current = p
+		log.Printf("loaded model trained at %s from %s", p.TrainedAt.Format("2006-01-02 15:04:05"), modelPath())
+	} else if _, err := retrain(); err != nil {
+		// The data services may still be seeding; /train or the first /predict retries.
+		log.Println("initial training skipped:", err)
+	}
+
+	http.HandleFunc("/predict", auth.Require(auth.ScopeRead, handlePredict))
+	http.HandleFunc("/train", auth.Require(auth.ScopeWrite, handleTrain))
+	http.HandleFunc("/calibration", auth.Require(auth.ScopeWrite, handleCalibration))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("cost-prediction-service listening on :8087")
 	log.Fatal(http.ListenAndServe(":8087", nil))
 }
 
+// retrain fits a fresh predictor from the database, persists it and makes it current.
+// Runs are serialised so concurrent /train calls don't interleave writes to the model file.
+func retrain() (*predictor, error) {
+	trainMu.Lock()
+	defer trainMu.Unlock()
+
+	p, err := train(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.save(modelPath()); err != nil {
+		return nil, err
+	}
+	modelMu.Lock()
+	current = p
+	modelMu.Unlock()
+	return p, nil
+}
+
+// activePredictor returns the current model, training one first if none exists yet.
+func activePredictor() (*predictor, error) {
+	modelMu.RLock()
+	p := current
+	modelMu.RUnlock()
+	if p != nil {
+		return p, nil
+	}
+	return retrain()
+}
+
+func handleTrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	p, err := retrain()
+	if err != nil {
+		http.Error(w, "training failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"trained_at":         p.TrainedAt,
+		"training_rows":      p.TrainingRows,
+		"r2":                 p.R2,
+		"feature_importance": p.featureImportance(),
+		"model_path":         modelPath(),
+	})
+}
+
 func handlePredict(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -36,39 +111,52 @@ func handlePredict(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Mock XGBoost-style prediction
-	baseRent := 3000.0 + float64(user.FamilySize)*1500
-	baseGroceries := 2000.0 + float64(user.FamilySize)*800
-	baseTransport := user.CommuteDistance * 8 * 26
+	p, err := activePredictor()
+	if err != nil {
+		http.Error(w, "model not trained: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 
-	rent := baseRent * (1 + (rand.Float64()-0.5)*0.2)
-	groceries := baseGroceries * (1 + (rand.Float64()-0.5)*0.15)
-	transport := baseTransport * (1 + (rand.Float64()-0.5)*0.1)
+	rentX, groceriesX, transportX := p.features(user)
+	rent, rentIv := p.Rent.predict(rentX)
+	groceries, groceriesIv := p.Groceries.predict(groceriesX)
+	transport, transportIv := 0.0, interval{}
+	if user.CommuteDistance > 0 {
+		transport, transportIv = p.Transport.predict(transportX)
+	}
 
 	total := rent + groceries + transport
+
+	// Components whose training data can't support an interval are left out of
+	// intervals and count only their median towards the total's.
+	intervals := map[string]interval{}
+	var served []interval
+	for _, c := range []struct {
+		name string
+		iv   interval
+	}{{"rent", rentIv}, {"groceries", groceriesIv}, {"transport", transportIv}} {
+		if p.IntervalSupported[c.name] {
+			intervals[c.name] = c.iv
+		}
+		served = append(served, p.served(c.name, c.iv))
+	}
+	intervals["total"] = sumIntervals(served...)
 	costBurden := 0.0
 	if user.Income > 0 {
 		costBurden = (total / user.Income) * 100
 	}
 
-	confidence := 85.0 + rand.Float64()*10
-
-	// Simulate model inference time
-	time.Sleep(100 * time.Millisecond)
-
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"user":         user.Name,
-		"income":       user.Income,
-		"rent":         rent,
-		"groceries":    groceries,
-		"transport":    transport,
-		"total":        total,
-		"cost_burden":  costBurden,
-		"confidence":   confidence,
-		"feature_importance": map[string]string{
-			"rent":      "45%",
-			"groceries": "32%",
-			"transport": "23%",
-		},
+		"user":               user.Name,
+		"income":             user.Income,
+		"rent":               rent,
+		"groceries":          groceries,
+		"transport":          transport,
+		"total":              total,
+		"cost_burden":        costBurden,
+		"intervals":          intervals,
+		"interval_supported": p.IntervalSupported,
+		"feature_importance": p.featureImportance(),
+		"model_trained_at":   p.TrainedAt,
 	})
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"rent-cost-analyzer/internal/gbm"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const defaultModelPath = "cost-model.json"
+
+// modelVersion is bumped whenever the persisted layout changes; older files are retrained.
+const modelVersion = 4
+
+// modelPath is where the trained predictor is persisted (MODEL_PATH env or default).
+func modelPath() string {
+	if p := os.Getenv("MODEL_PATH"); p != "" {
+		return p
+	}
+	return defaultModelPath
+}
+
+// interval is a prediction's P10/P50/P90 quantiles.
+type interval struct {
+	P10 float64 `json:"p10"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+}
+
+// component holds one cost's mean model and the quantile models behind its interval.
+type component struct {
+	Mean *gbm.Model `json:"mean"`
+	P10  *gbm.Model `json:"p10"`
+	P50  *gbm.Model `json:"p50"`
+	P90  *gbm.Model `json:"p90"`
+}
+
+// predict returns the point estimate and interval for x.
+func (c *component) predict(x []float64) (float64, interval) {
+	q := gbm.PredictQuantiles([]*gbm.Model{c.P10, c.P50, c.P90}, x)
+	return c.Mean.Predict(x), interval{P10: q[0], P50: q[1], P90: q[2]}
+}
+
+// point collapses an interval to its median, for components whose interval isn't supported.
+func (iv interval) point() interval {
+	return interval{P10: iv.P50, P50: iv.P50, P90: iv.P50}
+}
+
+// sumIntervals combines component intervals into one for their total. Medians add; the
+// lower and upper spreads are combined in quadrature, treating components as independent.
+// Pass unsupported components as points so they add to the median but not the spread.
+func sumIntervals(parts ...interval) interval {
+	var out interval
+	var lo, hi float64
+	for _, p := range parts {
+		out.P50 += p.P50
+		lo += (p.P50 - p.P10) * (p.P50 - p.P10)
+		hi += (p.P90 - p.P50) * (p.P90 - p.P50)
+	}
+	out.P10 = out.P50 - math.Sqrt(lo)
+	out.P90 = out.P50 + math.Sqrt(hi)
+	return out
+}
+
+// predictor bundles the three cost components with the lookups needed to turn a user
+// profile into feature rows.
+type predictor struct {
+	Version         int                `json:"version"`
+	TrainedAt       time.Time          `json:"trained_at"`
+	Rent            *component         `json:"rent"`
+	Groceries       *component         `json:"groceries"`
+	Transport       *component         `json:"transport"`
+	LocalityRent    map[string]float64 `json:"locality_rent"`
+	MeanRent        float64            `json:"mean_rent"`
+	SqftByBedrooms  map[int]float64    `json:"sqft_by_bedrooms"`
+	LatestInflation map[string]float64 `json:"latest_inflation"`
+	TrainingRows    map[string]int     `json:"training_rows"`
+	// IntervalSupported records, per component, whether its training data had the
+	// spread a P10–P90 interval needs (see intervalSupported).
+	IntervalSupported map[string]bool    `json:"interval_supported"`
+	R2                map[string]float64 `json:"r2"`
+}
+
+var (
+	trainMu sync.Mutex
+	modelMu sync.RWMutex
+	current *predictor
+)
+
+func loadPredictor(path string) (*predictor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p predictor
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	if p.Version != modelVersion {
+		return nil, fmt.Errorf("model file version %d, want %d", p.Version, modelVersion)
+	}
+	return &p, nil
+}
+
+// save writes the predictor atomically so a crash mid-write never leaves a truncated model.
+func (p *predictor) save(path string) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// bedroomsFor maps household size to the bedroom count a family would typically rent.
+func bedroomsFor(familySize int) int {
+	br := (familySize + 1) / 2
+	if br < 1 {
+		br = 1
+	}
+	if br > 3 {
+		br = 3
+	}
+	return br
+}
+
+// features turns a profile into one row per model, in the same layout used for training.
+func (p *predictor) features(u models.UserProfile) (rent, groceries, transport []float64) {
+	br := bedroomsFor(u.FamilySize)
+	sqft, ok := p.SqftByBedrooms[br]
+	if !ok {
+		var all []float64
+		for _, s := range p.SqftByBedrooms {
+			all = append(all, s)
+		}
+		sqft = median(all)
+	}
+	level, ok := p.LocalityRent[strings.ToLower(u.PreferredLocale)]
+	if !ok {
+		level = p.MeanRent
+	}
+
+	family := u.FamilySize
+	if family < 1 {
+		family = 1
+	}
+	rent = []float64{float64(br), sqft, level}
+	groceries = []float64{float64(family), p.LatestInflation["Food"]}
+	transport = []float64{u.CommuteDistance, p.LatestInflation["Transport"]}
+	return
+}
+
+// served returns the interval /predict reports for a component: its own when supported,
+// otherwise just its median.
+func (p *predictor) served(name string, iv interval) interval {
+	if p.IntervalSupported[name] {
+		return iv
+	}
+	return iv.point()
+}
+
+// featureImportance reports the gain-based importance of every model's features.
+func (p *predictor) featureImportance() map[string]map[string]float64 {
+	return map[string]map[string]float64{
+		"rent":      p.Rent.Mean.FeatureImportance(),
+		"groceries": p.Groceries.Mean.FeatureImportance(),
+		"transport": p.Transport.Mean.FeatureImportance(),
+	}
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/internal/gbm"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// Feature layouts for the three models. Predict builds rows in the same order.
+var (
+	rentFeatures      = []string{"bedrooms", "sqft", "locality_rent_level"}
+	groceryFeatures   = []string{"family_size", "food_inflation"}
+	transportFeatures = []string{"commute_distance", "transport_inflation"}
+)
+
+const (
+	weeksPerMonth       = 4.3
+	commuteDaysPerMonth = 26
+	maxTrainFamilySize  = 8
+	// minBasketCoverage is the share of items a source must quote in a month for that
+	// month's basket to become grocery training rows.
+	minBasketCoverage = 0.5
+)
+
+type dataset struct {
+	X [][]float64
+	y []float64
+}
+
+func (d *dataset) add(x []float64, y float64) {
+	d.X = append(d.X, x)
+	d.y = append(d.y, y)
+}
+
+// trainingData is everything read from the shared database for one training run.
+type trainingData struct {
+	rent, groceries, transport dataset
+	localityRent               map[string]float64
+	meanRent                   float64
+	sqftByBedrooms             map[int]float64
+	latestInflation            map[string]float64
+	listings                   []listing
+}
+
+func newTrainingData() *trainingData {
+	return &trainingData{
+		localityRent:    map[string]float64{},
+		sqftByBedrooms:  map[int]float64{},
+		latestInflation: map[string]float64{},
+	}
+}
+
+// loadTrainingData reads rental_listings, groceries, transport_routes and inflation_data and
+// turns them into one dataset per cost component.
+func loadTrainingData(c *sql.DB) (*trainingData, error) {
+	td := newTrainingData()
+
+	inflation, err := loadInflation(c)
+	if err != nil {
+		return nil, fmt.Errorf("inflation_data: %w", err)
+	}
+	for cat, series := range inflation {
+		td.latestInflation[cat] = series[len(series)-1].rate
+	}
+
+	td.listings, err = loadListings(c)
+	if err != nil {
+		return nil, fmt.Errorf("rental_listings: %w", err)
+	}
+	td.setRent(td.listings)
+	if err := td.loadGroceries(c, inflation["Food"]); err != nil {
+		return nil, fmt.Errorf("groceries: %w", err)
+	}
+	if err := td.loadTransport(c, inflation["Transport"]); err != nil {
+		return nil, fmt.Errorf("transport_routes: %w", err)
+	}
+	return td, nil
+}
+
+// inflationPoint is one month's rate for a category.
+type inflationPoint struct {
+	period time.Time
+	rate   float64
+}
+
+// rateAt returns the rate in force at t: the newest point at or before it, or the oldest
+// point when t precedes the series. series must be ordered oldest to newest.
+func rateAt(series []inflationPoint, t time.Time) float64 {
+	rate := series[0].rate
+	for _, p := range series {
+		if p.period.After(t) {
+			break
+		}
+		rate = p.rate
+	}
+	return rate
+}
+
+// loadInflation returns each category's rates ordered oldest to newest.
+func loadInflation(c *sql.DB) (map[string][]inflationPoint, error) {
+	rows, err := c.Query("SELECT category, period, rate FROM inflation_data ORDER BY period")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string][]inflationPoint{}
+	for rows.Next() {
+		var cat string
+		var p inflationPoint
+		if err := rows.Scan(&cat, &p.period, &p.rate); err != nil {
+			return nil, err
+		}
+		out[cat] = append(out[cat], p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out["Food"]) == 0 || len(out["Transport"]) == 0 {
+		return nil, errors.New("no Food/Transport rows")
+	}
+	return out, nil
+}
+
+// listing is the subset of a rental_listings row the rent model trains on.
+type listing struct {
+	locality       string
+	rent           float64
+	bedrooms, sqft int
+}
+
+// loadListings reads every listing, ordered by id so folds and training are reproducible.
+func loadListings(c *sql.DB) ([]listing, error) {
+	rows, err := c.Query("SELECT locality, rent, bedrooms, sqft FROM rental_listings ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []listing
+	for rows.Next() {
+		var l listing
+		if err := rows.Scan(&l.locality, &l.rent, &l.bedrooms, &l.sqft); err != nil {
+			return nil, err
+		}
+		l.locality = strings.ToLower(l.locality)
+		listings = append(listings, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(listings) == 0 {
+		return nil, errors.New("no rows")
+	}
+	return listings, nil
+}
+
+// setRent builds one row per listing. Locality is encoded as its mean rent so trees can
+// split on it as an ordered value.
+func (td *trainingData) setRent(listings []listing) {
+	sums, counts := map[string]float64{}, map[string]int{}
+	sqfts := map[int][]float64{}
+	for _, l := range listings {
+		sums[l.locality] += l.rent
+		counts[l.locality]++
+		td.meanRent += l.rent
+		sqfts[l.bedrooms] = append(sqfts[l.bedrooms], float64(l.sqft))
+	}
+	td.meanRent /= float64(len(listings))
+	for loc, sum := range sums {
+		td.localityRent[loc] = sum / float64(counts[loc])
+	}
+	for br, s := range sqfts {
+		td.sqftByBedrooms[br] = median(s)
+	}
+
+	for _, l := range listings {
+		td.rent.add(td.rentRow(l), l.rent)
+	}
+}
+
+// rentRow encodes a listing with this data's locality levels; unseen localities get the
+// overall mean rent.
+func (td *trainingData) rentRow(l listing) []float64 {
+	level, ok := td.localityRent[l.locality]
+	if !ok {
+		level = td.meanRent
+	}
+	return []float64{float64(l.bedrooms), float64(l.sqft), level}
+}
+
+// groceryItem is the subset of a groceries row a basket is priced from.
+type groceryItem struct {
+	price              float64
+	adultQty, childQty float64
+}
+
+// basket is one source's quoted prices in one calendar month, keyed by item id.
+type basket struct {
+	month  time.Time
+	source string
+	prices map[int]float64
+}
+
+// loadGroceries builds rows for every household size from the baskets observed in
+// grocery_price_history. Each (source, month) basket prices every item's adult and child
+// weekly quantity at that source's average quote, falling back to the listed price for
+// items it didn't quote, so the targets carry the real spread between vendors and months.
+// The family is split into adults and children the same way grocery-service's /basket
+// does. Without enough history, rows fall back to today's listed prices scaled by each
+// food inflation rate.
+func (td *trainingData) loadGroceries(c *sql.DB, food []inflationPoint) error {
+	items, err := loadGroceryItems(c)
+	if err != nil {
+		return err
+	}
+	baskets, err := loadBaskets(c, len(items))
+	if err != nil {
+		return fmt.Errorf("grocery_price_history: %w", err)
+	}
+	td.groceries = groceryRows(items, baskets, food)
+	return nil
+}
+
+// loadGroceryItems reads every item's listed price and weekly quantities.
+func loadGroceryItems(c *sql.DB) (map[int]groceryItem, error) {
+	rows, err := c.Query("SELECT id, price, adult_weekly_qty, child_weekly_qty FROM groceries")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := map[int]groceryItem{}
+	for rows.Next() {
+		var id int
+		var it groceryItem
+		if err := rows.Scan(&id, &it.price, &it.adultQty, &it.childQty); err != nil {
+			return nil, err
+		}
+		items[id] = it
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("no rows")
+	}
+	return items, nil
+}
+
+// loadBaskets averages each item's quotes per source and UTC calendar month, keeping the
+// baskets that quote at least minBasketCoverage of the items.
+func loadBaskets(c *sql.DB, items int) ([]basket, error) {
+	rows, err := c.Query(`
+		SELECT date_trunc('month', observed_at AT TIME ZONE 'UTC') AS m, source, item_id, AVG(price)
+		FROM grocery_price_history
+		GROUP BY m, source, item_id
+		ORDER BY m, source`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var baskets []basket
+	for rows.Next() {
+		var m time.Time
+		var source string
+		var id int
+		var price float64
+		if err := rows.Scan(&m, &source, &id, &price); err != nil {
+			return nil, err
+		}
+		if n := len(baskets); n == 0 || !baskets[n-1].month.Equal(m) || baskets[n-1].source != source {
+			baskets = append(baskets, basket{month: m, source: source, prices: map[int]float64{}})
+		}
+		baskets[len(baskets)-1].prices[id] = price
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	kept := baskets[:0]
+	for _, b := range baskets {
+		if float64(len(b.prices)) >= minBasketCoverage*float64(items) {
+			kept = append(kept, b)
+		}
+	}
+	return kept, nil
+}
+
+// groceryRows turns baskets into one row per basket and household size, with the food
+// inflation rate in force that month as a feature. With no baskets it prices today's
+// listed basket at every food rate instead; those targets are an exact function of the
+// features, so the interval isn't supported (see intervalSupported).
+func groceryRows(items map[int]groceryItem, baskets []basket, food []inflationPoint) dataset {
+	var d dataset
+	addSizes := func(perAdult, perChild, rate, scale float64) {
+		for size := 1; size <= maxTrainFamilySize; size++ {
+			adults, children := models.SplitHousehold(size)
+			cost := (perAdult*float64(adults) + perChild*float64(children)) * weeksPerMonth
+			d.add([]float64{float64(size), rate}, cost*scale)
+		}
+	}
+
+	for _, b := range baskets {
+		var perAdult, perChild float64
+		for id, it := range items {
+			price, ok := b.prices[id]
+			if !ok {
+				price = it.price
+			}
+			perAdult += price * it.adultQty
+			perChild += price * it.childQty
+		}
+		addSizes(perAdult, perChild, rateAt(food, b.month), 1)
+	}
+	if len(baskets) > 0 {
+		return d
+	}
+
+	var perAdult, perChild float64
+	for _, it := range items {
+		perAdult += it.price * it.adultQty
+		perChild += it.price * it.childQty
+	}
+	for _, p := range food {
+		addSizes(perAdult, perChild, p.rate, 1+p.rate/100)
+	}
+	return d
+}
+
+// loadTransport builds rows for every route and observed transport inflation rate; the
+// target is the monthly round-trip commute cost.
+func (td *trainingData) loadTransport(c *sql.DB, transport []inflationPoint) error {
+	rows, err := c.Query("SELECT distance, fare FROM transport_routes")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dist, fare float64
+		if err := rows.Scan(&dist, &fare); err != nil {
+			return err
+		}
+		monthly := fare * 2 * commuteDaysPerMonth
+		for _, p := range transport {
+			td.transport.add([]float64{dist, p.rate}, monthly*(1+p.rate/100))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(td.transport.y) == 0 {
+		return errors.New("no rows")
+	}
+	return nil
+}
+
+// intervalSupported reports whether a dataset can support a quantile interval: some
+// feature vector must appear with different targets. Groceries priced without history
+// and transport from fares fixed by distance are exact functions of their features, so
+// their quantile models only reproduce one curve and the P10–P90 width is fitting noise.
+func intervalSupported(d dataset) bool {
+	type span struct{ lo, hi float64 }
+	spans := map[string]span{}
+	for i, x := range d.X {
+		key := fmt.Sprint(x)
+		s, ok := spans[key]
+		if !ok {
+			s = span{d.y[i], d.y[i]}
+		}
+		s.lo, s.hi = math.Min(s.lo, d.y[i]), math.Max(s.hi, d.y[i])
+		if s.hi-s.lo > 1e-9*math.Max(1, math.Abs(s.hi)) {
+			return true
+		}
+		spans[key] = s
+	}
+	return false
+}
+
+// intervalSupport checks each component's data with intervalSupported. Rent targets are
+// observed listings, which vary at any given set of features, so rent always has one.
+func (td *trainingData) intervalSupport() map[string]bool {
+	return map[string]bool{
+		"rent":      true,
+		"groceries": intervalSupported(td.groceries),
+		"transport": intervalSupported(td.transport),
+	}
+}
+
+func median(v []float64) float64 {
+	if len(v) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), v...)
+	sort.Float64s(s)
+	mid := len(s) / 2
+	if len(s)%2 == 0 {
+		return (s[mid-1] + s[mid]) / 2
+	}
+	return s[mid]
+}
+
+// train fits every component's models on freshly loaded data.
+func train(c *sql.DB) (*predictor, error) {
+	td, err := loadTrainingData(c)
+	if err != nil {
+		return nil, err
+	}
+
+	rent, err := trainComponent(rentFeatures, td.rent)
+	if err != nil {
+		return nil, fmt.Errorf("rent model: %w", err)
+	}
+	groceries, err := trainComponent(groceryFeatures, td.groceries)
+	if err != nil {
+		return nil, fmt.Errorf("groceries model: %w", err)
+	}
+	transport, err := trainComponent(transportFeatures, td.transport)
+	if err != nil {
+		return nil, fmt.Errorf("transport model: %w", err)
+	}
+
+	return &predictor{
+		Version:         modelVersion,
+		TrainedAt:       time.Now().UTC(),
+		Rent:            rent,
+		Groceries:       groceries,
+		Transport:       transport,
+		LocalityRent:    td.localityRent,
+		MeanRent:        td.meanRent,
+		SqftByBedrooms:  td.sqftByBedrooms,
+		LatestInflation: td.latestInflation,
+		TrainingRows: map[string]int{
+			"rent":      len(td.rent.y),
+			"groceries": len(td.groceries.y),
+			"transport": len(td.transport.y),
+		},
+		IntervalSupported: td.intervalSupport(),
+		R2: map[string]float64{
+			"rent":      rent.Mean.R2(td.rent.X, td.rent.y),
+			"groceries": groceries.Mean.R2(td.groceries.X, td.groceries.y),
+			"transport": transport.Mean.R2(td.transport.X, td.transport.y),
+		},
+	}, nil
+}
+
+// trainComponent fits the mean model and the P10/P50/P90 quantile models on one dataset.
+func trainComponent(features []string, d dataset) (*component, error) {
+	p := gbm.DefaultParams()
+	var comp component
+	targets := []struct {
+		m **gbm.Model
+		q float64
+	}{{&comp.Mean, 0}, {&comp.P10, 0.1}, {&comp.P50, 0.5}, {&comp.P90, 0.9}}
+	for _, t := range targets {
+		m, err := gbm.Train(features, d.X, d.y, p.WithQuantile(t.q))
+		if err != nil {
+			return nil, err
+		}
+		*t.m = m
+	}
+	return &comp, nil
+}
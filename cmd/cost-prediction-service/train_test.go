@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func month(m time.Month) time.Time {
+	return time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRateAt(t *testing.T) {
+	series := []inflationPoint{{month(3), 4}, {month(5), 6}}
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{month(1), 4}, // before the series: oldest
+		{month(3), 4},
+		{month(4), 4},
+		{month(5), 6},
+		{month(12), 6}, // after it: newest
+	}
+	for _, tt := range tests {
+		if got := rateAt(series, tt.at); got != tt.want {
+			t.Errorf("rateAt(%s) = %v, want %v", tt.at.Format("2006-01"), got, tt.want)
+		}
+	}
+}
+
+func TestGroceryRows(t *testing.T) {
+	items := map[int]groceryItem{
+		1: {price: 100, adultQty: 1, childQty: 0.5},
+		2: {price: 50, adultQty: 2, childQty: 1},
+	}
+	food := []inflationPoint{{month(1), 5}, {month(2), 7}}
+
+	t.Run("baskets", func(t *testing.T) {
+		baskets := []basket{
+			{month: month(1), source: "DMart", prices: map[int]float64{1: 100, 2: 50}},
+			// Item 2 isn't quoted, so it keeps its listed price.
+			{month: month(1), source: "Blinkit", prices: map[int]float64{1: 110}},
+			{month: month(2), source: "DMart", prices: map[int]float64{1: 104, 2: 52}},
+		}
+		d := groceryRows(items, baskets, food)
+		if len(d.y) != len(baskets)*maxTrainFamilySize {
+			t.Fatalf("got %d rows, want %d", len(d.y), len(baskets)*maxTrainFamilySize)
+		}
+		// Size 1 is one adult: DMart 100+100, Blinkit 110+100, a month later 104+104.
+		wants := []struct{ rate, cost float64 }{{5, 200}, {5, 210}, {7, 208}}
+		for i, w := range wants {
+			x, y := d.X[i*maxTrainFamilySize], d.y[i*maxTrainFamilySize]
+			if x[0] != 1 || x[1] != w.rate || math.Abs(y-w.cost*weeksPerMonth) > 1e-9 {
+				t.Errorf("basket %d size 1 = %v → %v, want rate %v and cost %v", i, x, y, w.rate, w.cost*weeksPerMonth)
+			}
+		}
+		if !intervalSupported(d) {
+			t.Error("intervalSupported() = false for baskets that differ in the same month")
+		}
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		d := groceryRows(items, nil, food)
+		if len(d.y) != len(food)*maxTrainFamilySize {
+			t.Fatalf("got %d rows, want %d", len(d.y), len(food)*maxTrainFamilySize)
+		}
+		if intervalSupported(d) {
+			t.Error("intervalSupported() = true for targets that are a function of the features")
+		}
+	})
+}
+
+func TestIntervalSupported(t *testing.T) {
+	tests := []struct {
+		name string
+		d    dataset
+		want bool
+	}{
+		{"empty", dataset{}, false},
+		{"unique features", dataset{X: [][]float64{{1, 5}, {2, 5}}, y: []float64{10, 20}}, false},
+		{"repeated features, same target", dataset{X: [][]float64{{1, 5}, {1, 5}}, y: []float64{10, 10}}, false},
+		{"repeated features, different targets", dataset{X: [][]float64{{1, 5}, {2, 5}, {1, 5}}, y: []float64{10, 20, 11}}, true},
+	}
+	for _, tt := range tests {
+		if got := intervalSupported(tt.d); got != tt.want {
+			t.Errorf("%s: intervalSupported() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSumIntervals(t *testing.T) {
+	rent := interval{P10: 14000, P50: 17000, P90: 21000}
+	groceries := interval{P10: 2000, P50: 6000, P90: 9000}
+
+	// Spreads of 3000 and 4000 on each side combine to 5000.
+	if got, want := sumIntervals(rent, groceries), (interval{P10: 18000, P50: 23000, P90: 28000}); got != want {
+		t.Errorf("sumIntervals() = %+v, want %+v", got, want)
+	}
+	// An unsupported component adds its median but no spread.
+	if got, want := sumIntervals(rent, groceries.point()), (interval{P10: 20000, P50: 23000, P90: 27000}); got != want {
+		t.Errorf("sumIntervals() with a point = %+v, want %+v", got, want)
+	}
+}
+
+func TestScoreIntervals(t *testing.T) {
+	iv := interval{P10: 90, P50: 100, P90: 110}
+	got := scoreIntervals([]float64{80, 95, 100, 110, 120}, []interval{iv, iv, iv, iv, iv})
+	want := calibrationScore{
+		HeldOut:           5,
+		Coverage:          0.6,
+		BelowP10:          0.2,
+		AboveP90:          0.2,
+		MeanIntervalWidth: 20,
+		P50MeanAbsError:   (20 + 5 + 0 + 10 + 20) / 5.0,
+	}
+	if got != want {
+		t.Errorf("scoreIntervals() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	upstreamTimeout = 30 * time.Second
+	healthTimeout   = 2 * time.Second
+)
+
+// upstream is one backing service, exposed under /api/<name>/.
+type upstream struct {
+	name   string
+	envVar string
+	defURL string
+}
+
+var upstreams = []upstream{
+	{"user", "USER_SERVICE_URL", "http://localhost:8081"},
+	{"rental", "RENTAL_SERVICE_URL", "http://localhost:8082"},
+	{"grocery", "GROCERY_SERVICE_URL", "http://localhost:8083"},
+	{"transport", "TRANSPORT_SERVICE_URL", "http://localhost:8084"},
+	{"inflation", "INFLATION_SERVICE_URL", "http://localhost:8085"},
+	{"geospatial", "GEOSPATIAL_SERVICE_URL", "http://localhost:8086"},
+	{"prediction", "PREDICTION_SERVICE_URL", "http://localhost:8087"},
+}
+
+func (u upstream) url() string {
+	if v := os.Getenv(u.envVar); v != "" {
+		return v
+	}
+	return u.defURL
+}
+
+func main() {
+	transport := &retryTransport{base: newTransport(), retries: 2, backoff: 100 * time.Millisecond}
+
+	mux := http.NewServeMux()
+	targets := map[string]string{}
+	for _, up := range upstreams {
+		target, err := url.Parse(up.url())
+		if err != nil {
+			log.Fatalf("%s: bad upstream url %q: %v", up.envVar, up.url(), err)
+		}
+		targets[up.name] = target.String()
+
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.Transport = transport
+		proxy.ErrorHandler = proxyError(up.name)
+
+		prefix := "/api/" + up.name
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, withTimeout(proxy, upstreamTimeout)))
+		log.Printf("routing %s/ -> %s", prefix, target)
+	}
+	mux.HandleFunc("/health", handleHealth(targets))
+
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           withRequestID(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      upstreamTimeout + 5*time.Second,
+		IdleTimeout:       90 * time.Second,
+	}
+
+	log.Println("gateway listening on :8080")
+	log.Fatal(srv.ListenAndServe())
+}
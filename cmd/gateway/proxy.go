@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: upstreamTimeout,
+	}
+}
+
+// retryTransport retries idempotent, body-less requests (GET, HEAD) when the upstream
+// can't be reached or answers 502/503/504. Other requests are sent exactly once.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		(req.Body == nil || req.Body == http.NoBody)
+	if !idempotent {
+		return t.base.RoundTrip(req)
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = t.base.RoundTrip(req)
+		if attempt == t.retries || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		log.Printf("[%s] retrying %s %s (attempt %d): %s", req.Header.Get(requestIDHeader),
+			req.Method, req.URL, attempt+2, failure(resp, err))
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.backoff << attempt):
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func failure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+// withTimeout bounds the whole upstream exchange.
+func withTimeout(h http.Handler, d time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// statusRecorder captures the status code for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// withRequestID gives every request an X-Request-ID (keeping one the client sent), echoes
+// it on the response and logs the request when it completes.
+func withRequestID(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(requestIDHeader, id)
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		log.Printf("[%s] %s %s %d %s", id, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func proxyError(name string) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusBadGateway
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		log.Printf("[%s] %s upstream error: %v", r.Header.Get(requestIDHeader), name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": name + " service unavailable"})
+	}
+}
+
+// handleHealth checks every upstream's /health in parallel. It answers 200 when all are
+// up and 503 otherwise, with per-service detail either way.
+func handleHealth(targets map[string]string) http.HandlerFunc {
+	client := &http.Client{Timeout: healthTimeout}
+	return func(w http.ResponseWriter, r *http.Request) {
+		type result struct {
+			Status    string `json:"status"`
+			LatencyMS int64  `json:"latency_ms"`
+			Error     string `json:"error,omitempty"`
+		}
+		results := make(map[string]result, len(targets))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, base := range targets {
+			wg.Add(1)
+			go func(name, base string) {
+				defer wg.Done()
+				start := time.Now()
+				res := result{Status: "up"}
+				resp, err := client.Get(base + "/health")
+				if err != nil {
+					res = result{Status: "down", Error: err.Error()}
+				} else {
+					resp.Body.Close()
+					if resp.StatusCode != http.StatusOK {
+						res = result{Status: "down", Error: resp.Status}
+					}
+				}
+				res.LatencyMS = time.Since(start).Milliseconds()
+				mu.Lock()
+				results[name] = res
+				mu.Unlock()
+			}(name, base)
+		}
+		wg.Wait()
+
+		status := "ok"
+		for _, res := range results {
+			if res.Status != "up" {
+				status = "degraded"
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "services": results})
+	}
+}
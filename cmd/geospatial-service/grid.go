@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultResolutionKm = 0.5
+	minResolutionKm     = 0.1
+	maxResolutionKm     = 5.0
+	maxGridCells        = 10000
+	maxBandwidthKm      = 10.0
+	// Kernel weights beyond this many bandwidths are ignored.
+	kernelCutoff = 3.0
+)
+
+// bbox is [minLon, minLat, maxLon, maxLat], the GeoJSON order.
+type bbox [4]float64
+
+func parseBBox(s string) (bbox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return bbox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	var b bbox
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return bbox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+		}
+		b[i] = f
+	}
+	if b[0] >= b[2] || b[1] >= b[3] || b[1] < -90 || b[3] > 90 || b[0] < -180 || b[2] > 180 {
+		return bbox{}, fmt.Errorf("bbox must have min < max and valid coordinates")
+	}
+	return b, nil
+}
+
+// gridCell is one populated cell of the grid. Median figures are from the listings inside
+// the cell; smoothed figures are Gaussian-kernel (Nadaraya–Watson) estimates at the cell
+// centre from all listings within three bandwidths, so cells without listings of their own
+// still get a value.
+type gridCell struct {
+	Row                 int        `json:"row"`
+	Col                 int        `json:"col"`
+	Center              geo.Point  `json:"center"`
+	Bounds              bbox       `json:"bounds"`
+	Count               int        `json:"count"`
+	MedianRent          *float64   `json:"median_rent,omitempty"`
+	MedianRentPerSqft   *float64   `json:"median_rent_per_sqft,omitempty"`
+	SmoothedRent        float64    `json:"smoothed_rent"`
+	SmoothedRentPerSqft float64    `json:"smoothed_rent_per_sqft"`
+	DensityPerKm2       float64    `json:"density_per_km2"`
+	Intensity           float64    `json:"intensity"`
+	listings            []listingV `json:"-"`
+}
+
+type listingV struct{ rent, perSqft float64 }
+
+type rentGrid struct {
+	BBox         bbox       `json:"bbox"`
+	ResolutionKm float64    `json:"resolution_km"`
+	BandwidthKm  float64    `json:"bandwidth_km"`
+	Rows         int        `json:"rows"`
+	Cols         int        `json:"cols"`
+	Listings     int        `json:"listings"`
+	Cells        []gridCell `json:"cells"`
+}
+
+// handleHeatmapGrid serves GET /heatmap/grid: listings binned into square cells of
+// resolution km (default 0.5) over bbox (default the Ashta bounding box), with per-cell
+// medians and kernel-smoothed rent. bandwidth_km sets the kernel width (default twice the
+// resolution). Only cells within reach of some listing are returned.
+func handleHeatmapGrid(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	asGeoJSON, err := geo.WantGeoJSON(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	box := bbox{locality.MinLon, locality.MinLat, locality.MaxLon, locality.MaxLat}
+	if v := q.Get("bbox"); v != "" {
+		if box, err = parseBBox(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	res := defaultResolutionKm
+	if v := q.Get("resolution"); v != "" {
+		res, err = strconv.ParseFloat(v, 64)
+		if err != nil || res < minResolutionKm || res > maxResolutionKm {
+			http.Error(w, fmt.Sprintf("resolution must be %g-%g km", minResolutionKm, maxResolutionKm), http.StatusBadRequest)
+			return
+		}
+	}
+	bandwidth := 2 * res
+	if v := q.Get("bandwidth_km"); v != "" {
+		bandwidth, err = strconv.ParseFloat(v, 64)
+		if err != nil || bandwidth <= 0 || bandwidth > maxBandwidthKm {
+			http.Error(w, fmt.Sprintf("bandwidth_km must be in (0, %g]", maxBandwidthKm), http.StatusBadRequest)
+			return
+		}
+	}
+
+	listings, err := listingPoints()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	grid, err := buildGrid(box, res, bandwidth, listings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if asGeoJSON {
+		fc := geo.NewCollection()
+		for _, c := range grid.Cells {
+			props := map[string]interface{}{
+				"row": c.Row, "col": c.Col, "count": c.Count,
+				"smoothed_rent": c.SmoothedRent, "smoothed_rent_per_sqft": c.SmoothedRentPerSqft,
+				"density_per_km2": c.DensityPerKm2, "intensity": c.Intensity,
+			}
+			if c.MedianRent != nil {
+				props["median_rent"] = *c.MedianRent
+			}
+			if c.MedianRentPerSqft != nil {
+				props["median_rent_per_sqft"] = *c.MedianRentPerSqft
+			}
+			b := c.Bounds
+			fc.AddPolygon([][2]float64{{b[0], b[1]}, {b[2], b[1]}, {b[2], b[3]}, {b[0], b[3]}}, props)
+		}
+		fc.Write(w)
+		return
+	}
+	json.NewEncoder(w).Encode(grid)
+}
+
+// buildGrid bins listings into cells of resKm over box and smooths with a Gaussian kernel
+// of bandwidthKm. Cells are sized in km at the box's middle latitude.
+func buildGrid(box bbox, resKm, bandwidthKm float64, listings []models.RentalListing) (rentGrid, error) {
+	midLat := (box[1] + box[3]) / 2
+	dLat := resKm / geo.KmPerDegree
+	dLon := resKm / (geo.KmPerDegree * math.Cos(midLat*math.Pi/180))
+	rows := int(math.Ceil((box[3] - box[1]) / dLat))
+	cols := int(math.Ceil((box[2] - box[0]) / dLon))
+	if rows*cols > maxGridCells {
+		return rentGrid{}, fmt.Errorf("grid would have %d cells (max %d); use a coarser resolution or smaller bbox", rows*cols, maxGridCells)
+	}
+
+	grid := rentGrid{BBox: box, ResolutionKm: resKm, BandwidthKm: bandwidthKm, Rows: rows, Cols: cols, Cells: []gridCell{}}
+	cells := make([]gridCell, rows*cols)
+	for i := range cells {
+		row, col := i/cols, i%cols
+		minLon, minLat := box[0]+float64(col)*dLon, box[1]+float64(row)*dLat
+		cells[i] = gridCell{
+			Row: row, Col: col,
+			Center: geo.Point{Lat: round6(minLat + dLat/2), Lon: round6(minLon + dLon/2)},
+			Bounds: bbox{round6(minLon), round6(minLat), round6(minLon + dLon), round6(minLat + dLat)},
+		}
+	}
+
+	var inBox []models.RentalListing
+	for _, l := range listings {
+		if l.Lon < box[0] || l.Lon >= box[2] || l.Lat < box[1] || l.Lat >= box[3] {
+			continue
+		}
+		inBox = append(inBox, l)
+		c := &cells[cellOf(l.Lat, box[1], dLat, rows)*cols+cellOf(l.Lon, box[0], dLon, cols)]
+		c.Count++
+		c.listings = append(c.listings, listingV{rent: l.Rent, perSqft: perSqft(l)})
+	}
+	grid.Listings = len(inBox)
+
+	// Gaussian kernel density (listings per km²) and kernel-weighted means at each centre.
+	norm := 1 / (2 * math.Pi * bandwidthKm * bandwidthKm)
+	for i := range cells {
+		c := &cells[i]
+		var wSum, rentSum, sqftW, sqftSum float64
+		for _, l := range inBox {
+			d := geo.DistanceKm(c.Center, geo.Point{Lat: l.Lat, Lon: l.Lon})
+			if d > kernelCutoff*bandwidthKm {
+				continue
+			}
+			k := math.Exp(-d * d / (2 * bandwidthKm * bandwidthKm))
+			wSum += k
+			rentSum += k * l.Rent
+			if ps := perSqft(l); ps > 0 {
+				sqftW += k
+				sqftSum += k * ps
+			}
+		}
+		if wSum == 0 {
+			continue
+		}
+		c.SmoothedRent = round2(rentSum / wSum)
+		if sqftW > 0 {
+			c.SmoothedRentPerSqft = round2(sqftSum / sqftW)
+		}
+		c.DensityPerKm2 = round4(norm * wSum)
+		if c.Count > 0 {
+			rents, per := make([]float64, 0, c.Count), make([]float64, 0, c.Count)
+			for _, v := range c.listings {
+				rents = append(rents, v.rent)
+				if v.perSqft > 0 {
+					per = append(per, v.perSqft)
+				}
+			}
+			m := round2(median(rents))
+			c.MedianRent = &m
+			if len(per) > 0 {
+				p := round2(median(per))
+				c.MedianRentPerSqft = &p
+			}
+		}
+		grid.Cells = append(grid.Cells, *c)
+	}
+
+	// Intensity scales smoothed rent to 0-1 across the returned cells.
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, c := range grid.Cells {
+		lo, hi = math.Min(lo, c.SmoothedRent), math.Max(hi, c.SmoothedRent)
+	}
+	for i := range grid.Cells {
+		if hi > lo {
+			grid.Cells[i].Intensity = round4((grid.Cells[i].SmoothedRent - lo) / (hi - lo))
+		} else {
+			grid.Cells[i].Intensity = 1
+		}
+	}
+	return grid, nil
+}
+
+// cellOf returns the index of the step-wide cell holding v, counting from min, clamped to
+// the n cells. A coordinate just inside the box's max edge can otherwise divide out to n.
+func cellOf(v, min, step float64, n int) int {
+	i := int((v - min) / step)
+	if i >= n {
+		return n - 1
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+func perSqft(l models.RentalListing) float64 {
+	if l.Sqft <= 0 {
+		return 0
+	}
+	return l.Rent / float64(l.Sqft)
+}
+
+func median(vs []float64) float64 {
+	sort.Float64s(vs)
+	n := len(vs)
+	if n%2 == 1 {
+		return vs[n/2]
+	}
+	return (vs[n/2-1] + vs[n/2]) / 2
+}
+
+func round4(v float64) float64 { return math.Round(v*1e4) / 1e4 }
+func round6(v float64) float64 { return math.Round(v*1e6) / 1e6 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/pkg/models"
+)
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		name string
+		v    float64
+		want int
+	}{
+		{"min edge", 10, 0},
+		{"inside", 10.25, 1},
+		{"just below max", math.Nextafter(11, 0), 3},
+		// Rounding can put a coordinate inside the box at exactly n steps.
+		{"divides out to n", 11, 3},
+		{"below min", 9.99, 0},
+	}
+	for _, tt := range tests {
+		if got := cellOf(tt.v, 10, 0.25, 4); got != tt.want {
+			t.Errorf("%s: cellOf(%v) = %d, want %d", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+// testGrid is a box three 1 km cells on a side (a hair under, so rounding doesn't add a
+// fourth), with dLat and dLon in degrees for placing listings.
+func testGrid() (box bbox, dLat, dLon float64) {
+	const lat0, lon0 = 18.5, 73.8
+	dLat = 1 / geo.KmPerDegree
+	midLat := lat0 + 1.5*dLat
+	dLon = 1 / (geo.KmPerDegree * math.Cos(midLat*math.Pi/180))
+	return bbox{lon0, lat0, lon0 + 2.999*dLon, lat0 + 2.999*dLat}, dLat, dLon
+}
+
+// cellAt finds the returned cell at row, col.
+func cellAt(g rentGrid, row, col int) (gridCell, bool) {
+	for _, c := range g.Cells {
+		if c.Row == row && c.Col == col {
+			return c, true
+		}
+	}
+	return gridCell{}, false
+}
+
+func TestBuildGridEdgeBinning(t *testing.T) {
+	box, _, _ := testGrid()
+	listings := []models.RentalListing{
+		{Rent: 20000, Sqft: 800, Lat: math.Nextafter(box[3], 0), Lon: math.Nextafter(box[2], 0)},
+		// On the max edge itself: outside the half-open box.
+		{Rent: 20000, Sqft: 800, Lat: box[3], Lon: box[0]},
+	}
+	g, err := buildGrid(box, 1, 2, listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Rows != 3 || g.Cols != 3 || g.Listings != 1 {
+		t.Fatalf("grid %dx%d with %d listings, want 3x3 with 1", g.Rows, g.Cols, g.Listings)
+	}
+	if c, ok := cellAt(g, 2, 2); !ok || c.Count != 1 {
+		t.Errorf("corner cell = %+v, want the listing", c)
+	}
+}
+
+func TestBuildGridCells(t *testing.T) {
+	box, dLat, dLon := testGrid()
+	at := func(rent float64, sqft int, dy, dx float64) models.RentalListing {
+		return models.RentalListing{Rent: rent, Sqft: sqft, Lat: box[1] + (0.5+dy)*dLat, Lon: box[0] + (0.5+dx)*dLon}
+	}
+	// Three listings around cell (0,0)'s centre; the one without sqft has no per-sqft rent.
+	listings := []models.RentalListing{
+		at(10000, 500, 0, 0),
+		at(30000, 1000, 0.01, 0),
+		at(20000, 0, 0, 0.01),
+	}
+	// A 0.5 km bandwidth reaches 1.5 km: the neighbouring centres at 1 km and the
+	// diagonal at 1.41 km, but not the far row and column 2 km or more away.
+	g, err := buildGrid(box, 1, 0.5, listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := cellAt(g, 0, 0)
+	if !ok {
+		t.Fatal("cell (0,0) missing")
+	}
+	if c.Count != 3 || c.MedianRent == nil || *c.MedianRent != 20000 || c.MedianRentPerSqft == nil || *c.MedianRentPerSqft != 25 {
+		t.Errorf("cell (0,0) = count %d, median rent %v, per sqft %v; want 3, 20000, 25", c.Count, c.MedianRent, c.MedianRentPerSqft)
+	}
+
+	for _, rc := range [][2]int{{0, 1}, {1, 0}, {1, 1}} {
+		c, ok := cellAt(g, rc[0], rc[1])
+		if !ok {
+			t.Errorf("cell %v missing, want a smoothed value", rc)
+			continue
+		}
+		if c.Count != 0 || c.MedianRent != nil || c.MedianRentPerSqft != nil {
+			t.Errorf("cell %v = %+v, want no listings or medians", rc, c)
+		}
+		if c.SmoothedRent < 10000 || c.SmoothedRent > 30000 || c.DensityPerKm2 <= 0 {
+			t.Errorf("cell %v smoothed rent %v, density %v; want a weighted rent and some density", rc, c.SmoothedRent, c.DensityPerKm2)
+		}
+	}
+	if len(g.Cells) != 4 {
+		t.Errorf("got %d cells, want the 4 within reach", len(g.Cells))
+	}
+}
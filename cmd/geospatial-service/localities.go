@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// handleLocalities serves GET /localities (the registry) and POST /localities (register
+// one). Registered names are what listings and routes must use.
+func handleLocalities(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodGet {
+		list, err := locality.List(conn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"localities": list})
+		return
+	}
+
+	var l models.Locality
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	l.Name = strings.TrimSpace(l.Name)
+	if errs := locality.Validate(l); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if _, err := locality.Get(conn, l.Name); err == nil {
+		writeValidationError(w, map[string]string{"name": "already registered"})
+		return
+	}
+	if l.Slug == "" {
+		l.Slug = locality.Slug(l.Name)
+	}
+	if _, err := locality.Get(conn, l.Slug); err == nil {
+		writeValidationError(w, map[string]string{"slug": "already registered"})
+		return
+	}
+
+	l, err := locality.Insert(conn, l)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(l)
+}
+
+// handleLocalityByRef serves GET /localities/{name or slug}.
+func handleLocalityByRef(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	l, ok := lookupLocality(w, strings.TrimPrefix(r.URL.Path, "/localities/"))
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(l)
+}
+
+// lookupLocality resolves a name or slug, writing 404 for an unknown locality and 500 for
+// a lookup failure.
+func lookupLocality(w http.ResponseWriter, ref string) (models.Locality, bool) {
+	l, err := locality.Get(conn, ref)
+	if errors.Is(err, locality.ErrUnknown) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return l, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return l, false
+	}
+	return l, true
+}
+
+// addLocality adds a locality's boundary polygon, or its centroid if it has no boundary.
+func addLocality(fc *geo.FeatureCollection, loc models.Locality, props map[string]interface{}) {
+	if len(loc.Boundary) >= 4 {
+		fc.AddPolygon(loc.Boundary, props)
+		return
+	}
+	fc.AddPoint(geo.Point{Lat: loc.Lat, Lon: loc.Lon}, props)
+}
+
+func writeValidationError(w http.ResponseWriter, errs map[string]string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "validation failed", "fields": errs})
+}
@@ -6,12 +6,19 @@ import (
 	"log"
 	"net/http"
 
+	"rent-cost-analyzer/internal/auth"
 	"rent-cost-analyzer/internal/db"
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
 )
 
 var conn *sql.DB
 
 func main() {
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
 	c, err := db.Open()
 	if err != nil {
 		log.Fatal("db open:", err)
@@ -19,10 +26,18 @@ func main() {
 	defer c.Close()
 	conn = c
 
-	// Uses rental_listings table (read-only); no tables created here
+	// Owns the locality registry; reads rental_listings.
+	if n, err := db.MigrateUp(conn, "locality"); err != nil {
+		log.Fatal("migrate:", err)
+	} else if n > 0 {
+		log.Printf("applied %d locality migration(s)", n)
+	}
 
-	http.HandleFunc("/heatmap", handleHeatmap)
-	http.HandleFunc("/nearby", handleNearby)
+	http.HandleFunc("/localities", auth.ByMethod(handleLocalities))
+	http.HandleFunc("/localities/", auth.Require(auth.ScopeRead, handleLocalityByRef))
+	http.HandleFunc("/heatmap", auth.Require(auth.ScopeRead, handleHeatmap))
+	http.HandleFunc("/heatmap/grid", auth.Require(auth.ScopeRead, handleHeatmapGrid))
+	http.HandleFunc("/nearby", auth.Require(auth.ScopeRead, handleNearby))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("geospatial-service listening on :8086")
@@ -35,11 +50,17 @@ func handleHeatmap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
+	asGeoJSON, err := geo.WantGeoJSON(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	rows, err := conn.Query(`
-		SELECT locality, AVG(rent) as avg_rent, COUNT(*) as count
-		FROM rental_listings
-		GROUP BY locality
+		SELECT loc.name, loc.slug, loc.lat, loc.lon, AVG(l.rent) as avg_rent, COUNT(*) as count
+		FROM rental_listings l
+		JOIN localities loc ON loc.name = l.locality
+		GROUP BY loc.id
 		ORDER BY avg_rent DESC
 	`)
 	if err != nil {
@@ -50,6 +71,9 @@ func handleHeatmap(w http.ResponseWriter, r *http.Request) {
 
 	type localityRow struct {
 		Locality string  `json:"locality"`
+		Slug     string  `json:"slug"`
+		Lat      float64 `json:"lat"`
+		Lon      float64 `json:"lon"`
 		AvgRent  float64 `json:"avg_rent"`
 		Count    int     `json:"count"`
 	}
@@ -58,7 +82,7 @@ func handleHeatmap(w http.ResponseWriter, r *http.Request) {
 	var maxRent float64
 	for rows.Next() {
 		var row localityRow
-		rows.Scan(&row.Locality, &row.AvgRent, &row.Count)
+		rows.Scan(&row.Locality, &row.Slug, &row.Lat, &row.Lon, &row.AvgRent, &row.Count)
 		list = append(list, row)
 		if row.AvgRent > maxRent {
 			maxRent = row.AvgRent
@@ -68,6 +92,9 @@ func handleHeatmap(w http.ResponseWriter, r *http.Request) {
 	// Add intensity 0-1 for client
 	type withIntensity struct {
 		Locality  string  `json:"locality"`
+		Slug      string  `json:"slug"`
+		Lat       float64 `json:"lat"`
+		Lon       float64 `json:"lon"`
 		AvgRent   float64 `json:"avg_rent"`
 		Count     int     `json:"count"`
 		Intensity float64 `json:"intensity"`
@@ -79,56 +106,31 @@ func handleHeatmap(w http.ResponseWriter, r *http.Request) {
 			val = row.AvgRent / maxRent
 		}
 		result = append(result, withIntensity{
-			Locality: row.Locality, AvgRent: row.AvgRent, Count: row.Count,
+			Locality: row.Locality, Slug: row.Slug, Lat: row.Lat, Lon: row.Lon,
+			AvgRent: row.AvgRent, Count: row.Count,
 			Intensity: val,
 		})
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{"localities": result})
-}
-
-func handleNearby(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-
-	locality := r.URL.Query().Get("locality")
-	if locality == "" {
-		http.Error(w, "locality required", http.StatusBadRequest)
-		return
-	}
-
-	rows, err := conn.Query(`
-		SELECT locality, distance, lat, lon
-		FROM rental_listings
-		WHERE locality != $1
-		ORDER BY distance
-
-
-		
-		LIMIT 10
-	`, locality)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if asGeoJSON {
+		localities, err := locality.List(conn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		byName := map[string]models.Locality{}
+		for _, loc := range localities {
+			byName[loc.Name] = loc
+		}
+		fc := geo.NewCollection()
+		for _, row := range result {
+			addLocality(fc, byName[row.Locality], map[string]interface{}{
+				"locality": row.Locality, "slug": row.Slug, "avg_rent": row.AvgRent,
+				"count": row.Count, "intensity": row.Intensity,
+			})
+		}
+		fc.Write(w)
 		return
 	}
-	defer rows.Close()
-
-	type nearbyRow struct {
-		Locality string  `json:"locality"`
-		Distance float64 `json:"distance_km"`
-		Lat      float64 `json:"lat"`
-		Lon      float64 `json:"lon"`
-	}
-
-	var list []nearbyRow
-	for rows.Next() {
-		var row nearbyRow
-		rows.Scan(&row.Locality, &row.Distance, &row.Lat, &row.Lon)
-		list = append(list, row)
-	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{"center": locality, "nearby": list})
+	json.NewEncoder(w).Encode(map[string]interface{}{"localities": result})
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultRadiusKm = 5.0
+	maxRadiusKm     = 50.0
+)
+
+// nearbyCenter is where a /nearby search is measured from: a locality's centroid or a
+// point given directly.
+type nearbyCenter struct {
+	Locality string  `json:"locality,omitempty"`
+	Slug     string  `json:"slug,omitempty"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+// neighbour is one locality with listings inside the search radius. DistanceKm is from
+// the centre to the locality's centroid; the rent figures cover only its listings inside
+// the radius.
+type neighbour struct {
+	Locality     string  `json:"locality"`
+	Slug         string  `json:"slug"`
+	Lat          float64 `json:"lat"`
+	Lon          float64 `json:"lon"`
+	DistanceKm   float64 `json:"distance_km"`
+	NearestKm    float64 `json:"nearest_listing_km"`
+	AvgRent      float64 `json:"avg_rent"`
+	ListingCount int     `json:"listing_count"`
+}
+
+// handleNearby serves GET /nearby: the localities with listings within radius_km (default
+// 5, at most 50) of a locality's centroid (?locality=) or a point (?lat=&lon=), nearest
+// first. The centre locality itself is left out. With ?format=geojson the result is a
+// FeatureCollection of the centre, each neighbour's boundary and the listings found.
+func handleNearby(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	asGeoJSON, err := geo.WantGeoJSON(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	radius := defaultRadiusKm
+	if v := q.Get("radius_km"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > maxRadiusKm {
+			http.Error(w, fmt.Sprintf("radius_km must be in (0, %g]", maxRadiusKm), http.StatusBadRequest)
+			return
+		}
+		radius = f
+	}
+
+	var center nearbyCenter
+	switch {
+	case q.Get("locality") != "":
+		l, ok := lookupLocality(w, q.Get("locality"))
+		if !ok {
+			return
+		}
+		center = nearbyCenter{Locality: l.Name, Slug: l.Slug, Lat: l.Lat, Lon: l.Lon}
+	case q.Get("lat") != "" && q.Get("lon") != "":
+		lat, err1 := strconv.ParseFloat(q.Get("lat"), 64)
+		lon, err2 := strconv.ParseFloat(q.Get("lon"), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			http.Error(w, "lat and lon must be valid coordinates", http.StatusBadRequest)
+			return
+		}
+		center = nearbyCenter{Lat: lat, Lon: lon}
+	default:
+		http.Error(w, "locality or lat and lon required", http.StatusBadRequest)
+		return
+	}
+
+	localities, err := locality.List(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	listings, err := listingPoints()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	origin := geo.Point{Lat: center.Lat, Lon: center.Lon}
+	neighbours, inRadius := nearby(origin, center.Locality, radius, localities, listings)
+
+	if asGeoJSON {
+		fc := geo.NewCollection()
+		fc.AddPoint(origin, map[string]interface{}{"kind": "center", "locality": center.Locality, "radius_km": radius})
+		boundaries := map[string]models.Locality{}
+		for _, loc := range localities {
+			boundaries[loc.Name] = loc
+		}
+		for _, n := range neighbours {
+			props := map[string]interface{}{
+				"kind": "locality", "locality": n.Locality, "slug": n.Slug, "distance_km": n.DistanceKm,
+				"nearest_listing_km": n.NearestKm, "avg_rent": n.AvgRent, "listing_count": n.ListingCount,
+			}
+			addLocality(fc, boundaries[n.Locality], props)
+		}
+		for _, l := range inRadius {
+			fc.AddPoint(geo.Point{Lat: l.Lat, Lon: l.Lon}, map[string]interface{}{
+				"kind": "listing", "id": l.ID, "locality": l.Locality, "rent": l.Rent, "distance_km": round2(l.Distance),
+			})
+		}
+		fc.Write(w)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"center":    center,
+		"radius_km": radius,
+		"nearby":    neighbours,
+	})
+}
+
+// nearby groups the listings within radiusKm of center by locality, skipping exclude. It
+// also returns those listings, with Distance set to their distance from center.
+func nearby(center geo.Point, exclude string, radiusKm float64, localities []models.Locality, listings []models.RentalListing) ([]neighbour, []models.RentalListing) {
+	byName := map[string]*neighbour{}
+	rentSum := map[string]float64{}
+	var inRadius []models.RentalListing
+	for _, l := range listings {
+		if l.Locality == exclude {
+			continue
+		}
+		d := geo.DistanceKm(center, geo.Point{Lat: l.Lat, Lon: l.Lon})
+		if d > radiusKm {
+			continue
+		}
+		l.Distance = d
+		inRadius = append(inRadius, l)
+		n, ok := byName[l.Locality]
+		if !ok {
+			n = &neighbour{Locality: l.Locality, NearestKm: d}
+			byName[l.Locality] = n
+		}
+		n.NearestKm = math.Min(n.NearestKm, d)
+		n.ListingCount++
+		rentSum[l.Locality] += l.Rent
+	}
+
+	out := []neighbour{}
+	for _, loc := range localities {
+		n, ok := byName[loc.Name]
+		if !ok {
+			continue
+		}
+		n.Slug, n.Lat, n.Lon = loc.Slug, loc.Lat, loc.Lon
+		n.DistanceKm = round2(geo.DistanceKm(center, geo.Point{Lat: loc.Lat, Lon: loc.Lon}))
+		n.NearestKm = round2(n.NearestKm)
+		n.AvgRent = round2(rentSum[loc.Name] / float64(n.ListingCount))
+		out = append(out, *n)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DistanceKm != out[j].DistanceKm {
+			return out[i].DistanceKm < out[j].DistanceKm
+		}
+		return out[i].Locality < out[j].Locality
+	})
+	return out, inRadius
+}
+
+// listingPoints loads every listing with coordinates.
+func listingPoints() ([]models.RentalListing, error) {
+	rows, err := conn.Query(`
+		SELECT id, locality, rent, COALESCE(sqft, 0), lat, lon
+		FROM rental_listings
+		WHERE lat IS NOT NULL AND lon IS NOT NULL AND locality IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.RentalListing
+	for rows.Next() {
+		var l models.RentalListing
+		if err := rows.Scan(&l.ID, &l.Locality, &l.Rent, &l.Sqft, &l.Lat, &l.Lon); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/internal/auth"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	weeksPerMonth         = 4.3
+	defaultUserServiceURL = "http://localhost:8081"
+	maxHousehold          = 20
+)
+
+var userClient = &http.Client{Timeout: 5 * time.Second}
+
+// userServiceURL is where /basket?user_id= looks up profiles (USER_SERVICE_URL env or default).
+func userServiceURL() string {
+	if u := os.Getenv("USER_SERVICE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return defaultUserServiceURL
+}
+
+// loadItems returns every grocery item, most expensive first.
+func loadItems(c *sql.DB) ([]models.GroceryItem, error) {
+	rows, err := c.Query(`
+		SELECT id, item, price, source, unit, adult_weekly_qty, child_weekly_qty
+		FROM groceries ORDER BY price DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.GroceryItem
+	for rows.Next() {
+		var g models.GroceryItem
+		if err := rows.Scan(&g.ID, &g.Item, &g.Price, &g.Source, &g.Unit, &g.AdultWeeklyQty, &g.ChildWeeklyQty); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+// buildBasket prices a month of groceries for the given household.
+func buildBasket(items []models.GroceryItem, adults, children int) models.GroceryBasket {
+	b := models.GroceryBasket{Adults: adults, Children: children, Items: []models.BasketLine{}}
+	for _, it := range items {
+		weekly := it.AdultWeeklyQty*float64(adults) + it.ChildWeeklyQty*float64(children)
+		if weekly == 0 {
+			continue
+		}
+		monthly := weekly * weeksPerMonth
+		line := models.BasketLine{
+			Item:        it.Item,
+			Unit:        it.Unit,
+			Price:       it.Price,
+			Source:      it.Source,
+			WeeklyQty:   round2(weekly),
+			MonthlyQty:  round2(monthly),
+			MonthlyCost: round2(monthly * it.Price),
+		}
+		b.Items = append(b.Items, line)
+		b.WeeklyTotal += weekly * it.Price
+		b.MonthlyTotal += line.MonthlyCost
+	}
+	b.WeeklyTotal = round2(b.WeeklyTotal)
+	b.MonthlyTotal = round2(b.MonthlyTotal)
+	return b
+}
+
+// household is who a basket is priced for.
+type household struct {
+	userID, adults, children int
+}
+
+// parseHousehold reads the household from exactly one of ?user_id= (the profile's family
+// size), ?family_size=, or ?adults=&children=. With none given it returns def. On failure
+// it also returns the status to answer with.
+func parseHousehold(r *http.Request, def *household) (household, int, error) {
+	q := r.URL.Query()
+	var h household
+	var err error
+	switch {
+	case q.Get("user_id") != "":
+		h.userID, err = strconv.Atoi(q.Get("user_id"))
+		if err != nil || h.userID <= 0 {
+			return h, http.StatusBadRequest, errors.New("user_id must be a positive integer")
+		}
+		u, status, err := fetchUser(r, h.userID)
+		if err != nil {
+			return h, status, err
+		}
+		h.adults, h.children = models.SplitHousehold(u.FamilySize)
+	case q.Get("family_size") != "":
+		size, err := strconv.Atoi(q.Get("family_size"))
+		if err != nil || size < 1 || size > maxHousehold {
+			return h, http.StatusBadRequest, fmt.Errorf("family_size must be 1-%d", maxHousehold)
+		}
+		h.adults, h.children = models.SplitHousehold(size)
+	case q.Get("adults") != "":
+		if h.adults, err = strconv.Atoi(q.Get("adults")); err != nil || h.adults < 1 {
+			return h, http.StatusBadRequest, errors.New("adults must be at least 1")
+		}
+		if c := q.Get("children"); c != "" {
+			if h.children, err = strconv.Atoi(c); err != nil || h.children < 0 {
+				return h, http.StatusBadRequest, errors.New("children must be 0 or more")
+			}
+		}
+		if h.adults+h.children > maxHousehold {
+			return h, http.StatusBadRequest, fmt.Errorf("household larger than %d", maxHousehold)
+		}
+	case def != nil:
+		return *def, http.StatusOK, nil
+	default:
+		return h, http.StatusBadRequest, errors.New("user_id, family_size or adults required")
+	}
+	return h, http.StatusOK, nil
+}
+
+// handleBasket serves GET /basket for the household given in the query.
+func handleBasket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	h, status, err := parseHousehold(r, nil)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	items, err := loadItems(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	basket := buildBasket(items, h.adults, h.children)
+	basket.UserID = h.userID
+	json.NewEncoder(w).Encode(basket)
+}
+
+// fetchUser loads a profile from user-service with the caller's token. On failure it also
+// returns the status to answer with.
+func fetchUser(r *http.Request, id int) (*models.UserProfile, int, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet,
+		fmt.Sprintf("%s/users/%d", userServiceURL(), id), nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	auth.Forward(r, req)
+
+	resp, err := userClient.Do(req)
+	if err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("user-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, http.StatusNotFound, fmt.Errorf("user %d not found", id)
+	case http.StatusForbidden:
+		return nil, http.StatusForbidden, fmt.Errorf("user %d is not your profile", id)
+	default:
+		return nil, http.StatusBadGateway, fmt.Errorf("user-service: %s", resp.Status)
+	}
+	var u models.UserProfile
+	if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("user-service: %w", err)
+	}
+	return &u, http.StatusOK, nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
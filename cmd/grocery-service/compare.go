@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+// vendors are the sources seeded with prices for every item.
+var vendors = []string{"BigBasket", "Blinkit", "Local Mandi"}
+
+// latestQuotes returns each item's most recent price at every source, keyed by item id.
+func latestQuotes(c *sql.DB) (map[int][]models.PriceQuote, error) {
+	rows, err := c.Query(`
+		SELECT DISTINCT ON (item_id, source) item_id, source, price, observed_at, synthetic
+		FROM grocery_price_history
+		ORDER BY item_id, source, observed_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int][]models.PriceQuote{}
+	for rows.Next() {
+		var id int
+		var q models.PriceQuote
+		if err := rows.Scan(&id, &q.Source, &q.Price, &q.ObservedAt, &q.Synthetic); err != nil {
+			return nil, err
+		}
+		out[id] = append(out[id], q)
+	}
+	return out, rows.Err()
+}
+
+// comparePrices prices the household's basket at every source. Items with no recorded
+// quotes fall back to their listed price and source.
+func comparePrices(items []models.GroceryItem, quotes map[int][]models.PriceQuote, adults, children int) models.PriceComparison {
+	current := buildBasket(items, adults, children)
+	cmp := models.PriceComparison{
+		Adults:       adults,
+		Children:     children,
+		Items:        []models.ItemPrices{},
+		CurrentTotal: current.MonthlyTotal,
+		SplitVendor:  models.SplitBasket{BySource: map[string]float64{}},
+	}
+
+	totals := map[string]float64{}
+	stocked := map[string]int{}
+	type line struct {
+		item   string
+		quotes []models.PriceQuote
+	}
+	var needed []line
+	for _, it := range items {
+		monthly := (it.AdultWeeklyQty*float64(adults) + it.ChildWeeklyQty*float64(children)) * weeksPerMonth
+		if monthly == 0 {
+			continue
+		}
+		qs := append([]models.PriceQuote(nil), quotes[it.ID]...)
+		if len(qs) == 0 {
+			qs = []models.PriceQuote{{Source: it.Source, Price: it.Price}}
+		}
+		sort.SliceStable(qs, func(i, j int) bool { return qs[i].Price < qs[j].Price })
+		needed = append(needed, line{it.Item, qs})
+
+		cheapest := qs[0]
+		cmp.Items = append(cmp.Items, models.ItemPrices{
+			Item:           it.Item,
+			Unit:           it.Unit,
+			MonthlyQty:     round2(monthly),
+			Quotes:         qs,
+			CheapestSource: cheapest.Source,
+			CheapestPrice:  cheapest.Price,
+		})
+		cmp.SplitVendor.MonthlyTotal += monthly * cheapest.Price
+		cmp.SplitVendor.BySource[cheapest.Source] += monthly * cheapest.Price
+		for _, q := range qs {
+			totals[q.Source] += monthly * q.Price
+			stocked[q.Source]++
+		}
+	}
+
+	for source, total := range totals {
+		vb := models.VendorBasket{Source: source, MonthlyTotal: round2(total), Complete: stocked[source] == len(needed)}
+		if !vb.Complete {
+			for _, l := range needed {
+				if !hasSource(l.quotes, source) {
+					vb.Missing = append(vb.Missing, l.item)
+				}
+			}
+		}
+		cmp.SingleVendor = append(cmp.SingleVendor, vb)
+	}
+	// Complete baskets first, then cheapest.
+	sort.Slice(cmp.SingleVendor, func(i, j int) bool {
+		a, b := cmp.SingleVendor[i], cmp.SingleVendor[j]
+		if a.Complete != b.Complete {
+			return a.Complete
+		}
+		if a.MonthlyTotal != b.MonthlyTotal {
+			return a.MonthlyTotal < b.MonthlyTotal
+		}
+		return a.Source < b.Source
+	})
+
+	cmp.SplitVendor.MonthlyTotal = round2(cmp.SplitVendor.MonthlyTotal)
+	for s, v := range cmp.SplitVendor.BySource {
+		cmp.SplitVendor.BySource[s] = round2(v)
+	}
+	if len(cmp.SingleVendor) > 0 && cmp.SingleVendor[0].Complete {
+		cmp.CheapestVendor = cmp.SingleVendor[0].Source
+		cmp.Savings.SingleVendor = round2(cmp.CurrentTotal - cmp.SingleVendor[0].MonthlyTotal)
+	}
+	cmp.Savings.SplitVendor = round2(cmp.CurrentTotal - cmp.SplitVendor.MonthlyTotal)
+	return cmp
+}
+
+func hasSource(qs []models.PriceQuote, source string) bool {
+	for _, q := range qs {
+		if q.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCompare serves GET /compare: per-item cheapest source plus single- and split-vendor
+// baskets for the household in the query (one adult if none is given).
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	h, status, err := parseHousehold(r, &household{adults: 1})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	items, err := loadItems(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	quotes, err := latestQuotes(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cmp := comparePrices(items, quotes, h.adults, h.children)
+	cmp.UserID = h.userID
+	json.NewEncoder(w).Encode(cmp)
+}
+
+// seedVendorPrices runs only from seeding: it records a seeded item's listed price as a
+// quote, derives one for every other vendor and returns them all, marking every row
+// synthetic. Quick commerce charges a premium, the mandi is cheapest for fresh produce
+// and dearer for packaged goods, and a small per-item jitter keeps the cheapest vendor
+// from being the same for every item.
+func seedVendorPrices(tx *sql.Tx, id int, it models.GroceryItem) ([]models.PriceQuote, error) {
+	var quotes []models.PriceQuote
+	for _, v := range append([]string{it.Source}, vendors...) {
+		if hasSource(quotes, v) {
+			continue
+		}
+		price := round2(it.Price * vendorFactor(v, it.Item) / vendorFactor(it.Source, it.Item))
+		var at time.Time
+		if err := tx.QueryRow(`INSERT INTO grocery_price_history (item_id, source, price, synthetic)
+			VALUES ($1, $2, $3, TRUE) RETURNING observed_at`, id, v, price).Scan(&at); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, models.PriceQuote{Source: v, Price: price, ObservedAt: at, Synthetic: true})
+	}
+	return quotes, nil
+}
+
+func vendorFactor(vendor, item string) float64 {
+	fresh := strings.Contains(item, "Vegetables") || strings.Contains(item, "Milk")
+	packaged := strings.Contains(item, "Oil") || strings.Contains(item, "Tea")
+
+	h := fnv.New32a()
+	h.Write([]byte(vendor + "|" + item))
+	jitter := 1 + (float64(h.Sum32()%61)-30)/1000 // ±3%
+
+	switch vendor {
+	case "Blinkit":
+		return 1.07 * jitter
+	case "Local Mandi":
+		switch {
+		case fresh:
+			return 0.82 * jitter
+		case packaged:
+			return 1.04 * jitter
+		}
+		return 0.94 * jitter
+	}
+	return 1.0 * jitter
+}
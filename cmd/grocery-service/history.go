@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	maxObservationsPerRequest = 1000
+	seedHistoryMonths         = 12
+)
+
+// observationInput is one row of a POST /prices body. The item is given by id or name, and
+// observed_at is a date (YYYY-MM-DD) or an RFC 3339 timestamp.
+type observationInput struct {
+	ItemID     int     `json:"item_id"`
+	Item       string  `json:"item"`
+	Source     string  `json:"source"`
+	Price      float64 `json:"price"`
+	ObservedAt string  `json:"observed_at"`
+}
+
+func parseObservedAt(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// handlePrices serves POST /prices: ingest one observation or an array of them. The batch
+// is validated up front and written in one transaction; re-sending an observation for the
+// same item, source and time replaces its price.
+func handlePrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var inputs []observationInput
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(raw, &inputs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var in observationInput
+		if err := json.Unmarshal(raw, &in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		inputs = []observationInput{in}
+	}
+	if len(inputs) == 0 || len(inputs) > maxObservationsPerRequest {
+		http.Error(w, fmt.Sprintf("send 1-%d observations", maxObservationsPerRequest), http.StatusBadRequest)
+		return
+	}
+
+	items, err := loadItems(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	obs, errs := validateObservations(inputs, items, time.Now())
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if err := insertObservations(conn, obs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"ingested": len(obs)})
+}
+
+// validateObservations resolves each input's item and checks its fields. Errors are keyed
+// by row and field, e.g. "[2].price".
+func validateObservations(inputs []observationInput, items []models.GroceryItem, now time.Time) ([]models.PriceObservation, map[string]string) {
+	errs := map[string]string{}
+	var out []models.PriceObservation
+	for i, in := range inputs {
+		key := func(field string) string { return fmt.Sprintf("[%d].%s", i, field) }
+
+		it, ok := findItem(items, in.ItemID, in.Item)
+		if !ok {
+			errs[key("item")] = "unknown item"
+		}
+		source := strings.TrimSpace(in.Source)
+		if source == "" || len(source) > 50 {
+			errs[key("source")] = "required, at most 50 characters"
+		}
+		if in.Price <= 0 || in.Price > 1e7 {
+			errs[key("price")] = "must be positive"
+		}
+		at, err := parseObservedAt(in.ObservedAt)
+		if err != nil {
+			errs[key("observed_at")] = "want YYYY-MM-DD or an RFC 3339 timestamp"
+		} else if at.After(now) {
+			errs[key("observed_at")] = "must not be in the future"
+		}
+		if ok {
+			out = append(out, models.PriceObservation{
+				ItemID: it.ID, Item: it.Item, Source: source, Price: in.Price, ObservedAt: at,
+			})
+		}
+	}
+	return out, errs
+}
+
+// findItem matches by id when one is given, else by case-insensitive name.
+func findItem(items []models.GroceryItem, id int, name string) (models.GroceryItem, bool) {
+	for _, it := range items {
+		if (id > 0 && it.ID == id) || (id == 0 && name != "" && strings.EqualFold(it.Item, strings.TrimSpace(name))) {
+			return it, true
+		}
+	}
+	return models.GroceryItem{}, false
+}
+
+func insertObservations(c *sql.DB, obs []models.PriceObservation) error {
+	tx, err := c.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO grocery_price_history (item_id, source, price, observed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, source, observed_at) DO UPDATE SET price = EXCLUDED.price`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, o := range obs {
+		if _, err := stmt.Exec(o.ItemID, o.Source, o.Price, o.ObservedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func writeValidationError(w http.ResponseWriter, errs map[string]string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "validation failed", "fields": errs})
+}
+
+// handleItemByPath serves GET /items/{item}/history, where {item} is an id or a
+// URL-escaped name.
+func handleItemByPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	// Names like "Tea/Coffee" contain a slash, so split the escaped path.
+	rest := strings.TrimPrefix(r.URL.EscapedPath(), "/items/")
+	ref, ok := strings.CutSuffix(rest, "/history")
+	if !ok || ref == "" || strings.Contains(ref, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	ref, err := url.PathUnescape(ref)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := loadItems(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id, _ := strconv.Atoi(ref)
+	it, found := findItem(items, id, ref)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "item not found"})
+		return
+	}
+
+	q := r.URL.Query()
+	var from, to time.Time
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01", v); err != nil {
+			http.Error(w, "from must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01", v); err != nil {
+			http.Error(w, "to must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+
+	observedOnly := false
+	if v := q.Get("observed_only"); v != "" {
+		if observedOnly, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "observed_only must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	points, err := monthlyPrices(conn, it.ID, q.Get("source"), from, to, observedOnly)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(models.PriceHistory{
+		ItemID: it.ID, Item: it.Item, Unit: it.Unit, Source: q.Get("source"), Points: points,
+	})
+}
+
+// monthlyPrices aggregates an item's observations by calendar month (UTC), optionally for
+// one source and between from and to (inclusive months; zero means unbounded). With
+// observedOnly, seeded (synthetic) rows are left out.
+func monthlyPrices(c *sql.DB, itemID int, source string, from, to time.Time, observedOnly bool) ([]models.PricePoint, error) {
+	query := `
+		SELECT date_trunc('month', observed_at AT TIME ZONE 'UTC') AS m,
+		       AVG(price), MIN(price), MAX(price), COUNT(*), COUNT(*) FILTER (WHERE synthetic)
+		FROM grocery_price_history
+		WHERE item_id = $1`
+	args := []interface{}{itemID}
+	if source != "" {
+		args = append(args, source)
+		query += fmt.Sprintf(" AND LOWER(source) = LOWER($%d)", len(args))
+	}
+	if observedOnly {
+		query += " AND NOT synthetic"
+	}
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND observed_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to.AddDate(0, 1, 0))
+		query += fmt.Sprintf(" AND observed_at < $%d", len(args))
+	}
+	query += " GROUP BY m ORDER BY m"
+
+	rows, err := c.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.PricePoint{}
+	var prevMonth time.Time
+	for rows.Next() {
+		var m time.Time
+		var p models.PricePoint
+		if err := rows.Scan(&m, &p.AvgPrice, &p.MinPrice, &p.MaxPrice, &p.Observations, &p.Synthetic); err != nil {
+			return nil, err
+		}
+		p.Month = m.Format("2006-01")
+		p.AvgPrice = round2(p.AvgPrice)
+		if len(points) > 0 && prevMonth.AddDate(0, 1, 0).Equal(m) {
+			prev := points[len(points)-1].AvgPrice
+			pct := round2((p.AvgPrice - prev) / prev * 100)
+			p.MoMChangePct = &pct
+		}
+		points = append(points, p)
+		prevMonth = m
+	}
+	return points, rows.Err()
+}
+
+// seedPriceHistory back-fills a year of monthly observations, marked synthetic, before a
+// seeded item's quotes so /items/{item}/history has a series to show. Prices drift up
+// 0.4–0.8% a month per item, and fresh produce also swings seasonally. It only runs while
+// seeding.
+func seedPriceHistory(tx *sql.Tx, id int, it models.GroceryItem, quotes []models.PriceQuote) error {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, time.UTC)
+	h := fnv.New32a()
+	h.Write([]byte(it.Item))
+	rate := 0.004 + float64(h.Sum32()%5)/1000
+	fresh := strings.Contains(it.Item, "Vegetables") || strings.Contains(it.Item, "Milk")
+
+	for _, q := range quotes {
+		for k := 1; k <= seedHistoryMonths; k++ {
+			at := thisMonth.AddDate(0, -k, 0)
+			price := q.Price / math.Pow(1+rate, float64(k))
+			if fresh {
+				price *= 1 + 0.08*math.Sin(2*math.Pi*float64(at.Month())/12)
+			}
+			if _, err := tx.Exec(`INSERT INTO grocery_price_history (item_id, source, price, observed_at, synthetic)
+				VALUES ($1, $2, $3, $4, TRUE) ON CONFLICT DO NOTHING`, id, q.Source, round2(price), at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
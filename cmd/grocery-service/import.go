@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// groceryRow is one imported grocery price: the item's details plus when the price was seen.
+// The has* flags record which optional item columns the row supplied; the rest hold the
+// defaults for a new item.
+type groceryRow struct {
+	models.GroceryItem
+	observedAt time.Time
+
+	hasUnit, hasAdultQty, hasChildQty bool
+}
+
+// mergeInto returns stored with the item columns this row supplied replaced. The listed
+// price and source are left alone; Insert moves them only for the newest observation.
+func (g groceryRow) mergeInto(stored models.GroceryItem) models.GroceryItem {
+	if g.hasUnit {
+		stored.Unit = g.Unit
+	}
+	if g.hasAdultQty {
+		stored.AdultWeeklyQty = g.AdultWeeklyQty
+	}
+	if g.hasChildQty {
+		stored.ChildWeeklyQty = g.ChildWeeklyQty
+	}
+	return stored
+}
+
+// groceryImport imports groceries rows: item, price, source, and optionally unit,
+// adult_weekly_qty, child_weekly_qty and observed_at. Every row becomes a price
+// observation. An item that already exists (matched by name, case-insensitively) keeps any
+// optional column the row leaves out, and its listed price and source only move when the
+// row is the item's newest observation.
+var groceryImport = importer.Spec[groceryRow]{
+	Parse: func(row *importer.Row) groceryRow {
+		g := groceryRow{observedAt: time.Now()}
+		g.Item = row.String("item", true)
+		g.Price = row.Float("price")
+		g.Source = row.String("source", true)
+		g.Unit = row.String("unit", false)
+		g.hasUnit = g.Unit != ""
+		if !g.hasUnit {
+			g.Unit = "pack"
+		}
+		g.hasAdultQty = strings.TrimSpace(row.Fields["adult_weekly_qty"]) != ""
+		g.AdultWeeklyQty = row.OptionalFloat("adult_weekly_qty", 1)
+		g.hasChildQty = strings.TrimSpace(row.Fields["child_weekly_qty"]) != ""
+		g.ChildWeeklyQty = row.OptionalFloat("child_weekly_qty", 0.5)
+
+		if len(g.Item) > 100 {
+			row.Errorf("item", "at most 100 characters")
+		}
+		if len(g.Source) > 50 {
+			row.Errorf("source", "at most 50 characters")
+		}
+		if len(g.Unit) > 20 {
+			row.Errorf("unit", "at most 20 characters")
+		}
+		if g.Price <= 0 {
+			row.Errorf("price", "must be positive")
+		}
+		if g.AdultWeeklyQty < 0 {
+			row.Errorf("adult_weekly_qty", "must not be negative")
+		}
+		if g.ChildWeeklyQty < 0 {
+			row.Errorf("child_weekly_qty", "must not be negative")
+		}
+		if v := row.String("observed_at", false); v != "" {
+			at, err := parseObservedAt(v)
+			if err != nil {
+				row.Errorf("observed_at", "want YYYY-MM-DD or an RFC 3339 timestamp")
+			} else if at.After(time.Now()) {
+				row.Errorf("observed_at", "must not be in the future")
+			}
+			g.observedAt = at
+		}
+		return g
+	},
+	Insert: func(tx *sql.Tx, rows []groceryRow) error {
+		for _, g := range rows {
+			var stored models.GroceryItem
+			err := tx.QueryRow(`
+				SELECT id, unit, adult_weekly_qty, child_weekly_qty FROM groceries
+				WHERE LOWER(item) = LOWER($1)
+				FOR UPDATE`, g.Item).Scan(&stored.ID, &stored.Unit, &stored.AdultWeeklyQty, &stored.ChildWeeklyQty)
+			switch err {
+			case sql.ErrNoRows:
+				err = tx.QueryRow(`
+					INSERT INTO groceries (item, price, source, unit, adult_weekly_qty, child_weekly_qty)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					RETURNING id`, g.Item, g.Price, g.Source, g.Unit, g.AdultWeeklyQty, g.ChildWeeklyQty).Scan(&stored.ID)
+			case nil:
+				it := g.mergeInto(stored)
+				_, err = tx.Exec(`
+					UPDATE groceries SET unit = $2, adult_weekly_qty = $3, child_weekly_qty = $4
+					WHERE id = $1`, it.ID, it.Unit, it.AdultWeeklyQty, it.ChildWeeklyQty)
+			}
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(`
+				INSERT INTO grocery_price_history (item_id, source, price, observed_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (item_id, source, observed_at) DO UPDATE SET price = EXCLUDED.price`,
+				stored.ID, g.Source, g.Price, g.observedAt)
+			if err != nil {
+				return err
+			}
+			// The listed price follows the newest observation, not the last row imported.
+			_, err = tx.Exec(`
+				UPDATE groceries SET price = $2, source = $3
+				WHERE id = $1 AND NOT EXISTS (
+					SELECT 1 FROM grocery_price_history WHERE item_id = $1 AND observed_at > $4
+				)`, stored.ID, g.Price, g.Source, g.observedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
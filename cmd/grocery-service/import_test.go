@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// TestImportKeepsStoredColumns re-imports an existing item and checks that only the
+// columns the row supplies change.
+func TestImportKeepsStoredColumns(t *testing.T) {
+	stored := models.GroceryItem{ID: 9, Item: "Tea/Coffee", Price: 120, Source: "DMart", Unit: "250g", AdultWeeklyQty: 0.1, ChildWeeklyQty: 0}
+
+	tests := []struct {
+		name   string
+		format importer.Format
+		body   string
+		want   models.GroceryItem
+	}{
+		{
+			name:   "csv without quantity columns",
+			format: importer.CSV,
+			body:   "item,price,source\ntea/coffee,130,BigBasket\n",
+			want:   stored,
+		},
+		{
+			name:   "csv with empty quantity cells",
+			format: importer.CSV,
+			body:   "item,price,source,unit,adult_weekly_qty,child_weekly_qty\nTea/Coffee,130,BigBasket,,,\n",
+			want:   stored,
+		},
+		{
+			name:   "ndjson with null quantities",
+			format: importer.NDJSON,
+			body:   `{"item":"Tea/Coffee","price":130,"source":"BigBasket","adult_weekly_qty":null}`,
+			want:   stored,
+		},
+		{
+			name:   "supplied columns replace stored ones",
+			format: importer.CSV,
+			body:   "item,price,source,unit,child_weekly_qty\nTea/Coffee,130,BigBasket,500g,0.05\n",
+			want:   models.GroceryItem{ID: 9, Item: "Tea/Coffee", Price: 120, Source: "DMart", Unit: "500g", AdultWeeklyQty: 0.1, ChildWeeklyQty: 0.05},
+		},
+		{
+			name:   "zero is a supplied value",
+			format: importer.CSV,
+			body:   "item,price,source,adult_weekly_qty\nTea/Coffee,130,BigBasket,0\n",
+			want:   models.GroceryItem{ID: 9, Item: "Tea/Coffee", Price: 120, Source: "DMart", Unit: "250g", AdultWeeklyQty: 0, ChildWeeklyQty: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := importer.Read(strings.NewReader(tt.body), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := groceryImport.Parse(rows[0])
+			if errs := rows[0].Errors(); len(errs) > 0 {
+				t.Fatalf("row errors: %+v", errs)
+			}
+			if got := g.mergeInto(stored); got != tt.want {
+				t.Errorf("mergeInto() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImportNewItemDefaults checks the defaults a new item is inserted with.
+func TestImportNewItemDefaults(t *testing.T) {
+	rows, err := importer.Read(strings.NewReader("item,price,source\nMillet,80,Local\n"), importer.CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := groceryImport.Parse(rows[0])
+	if g.Unit != "pack" || g.AdultWeeklyQty != 1 || g.ChildWeeklyQty != 0.5 {
+		t.Errorf("new item = %+v, want unit pack and quantities 1 / 0.5", g.GroceryItem)
+	}
+}
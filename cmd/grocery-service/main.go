@@ -3,16 +3,26 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
 
+	"rent-cost-analyzer/internal/auth"
 	"rent-cost-analyzer/internal/db"
-	"rent-cost-analyzer/pkg/models"
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/internal/seed"
 )
 
 var conn *sql.DB
 
 func main() {
+	seedConfig := seed.FromEnv()
+	seedConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
 	c, err := db.Open()
 	if err != nil {
 		log.Fatal("db open:", err)
@@ -20,56 +30,51 @@ func main() {
 	defer c.Close()
 	conn = c
 
-	initTables(conn)
-	seedMockData(conn)
-
-	http.HandleFunc("/items", handleItems)
+	if n, err := db.MigrateUp(conn, "grocery"); err != nil {
+		log.Fatal("migrate:", err)
+	} else if n > 0 {
+		log.Printf("applied %d grocery migration(s)", n)
+	}
+	if _, err := seed.IfEmpty(conn, mockData, seedConfig); err != nil {
+		log.Println("seed groceries:", err)
+	}
+	http.HandleFunc("/items", auth.Require(auth.ScopeRead, handleItems))
+	http.HandleFunc("/items/", auth.Require(auth.ScopeRead, handleItemByPath))
+	http.HandleFunc("/prices", auth.Require(auth.ScopeWrite, handlePrices))
+	http.HandleFunc("/import", auth.Require(auth.ScopeWrite, importer.Handler(conn, groceryImport)))
+	http.HandleFunc("/basket", auth.Require(auth.ScopeRead, handleBasket))
+	http.HandleFunc("/compare", auth.Require(auth.ScopeRead, handleCompare))
+	http.HandleFunc("/admin/reset-and-seed", auth.Require(auth.ScopeAdmin, seed.Handler(conn, seedConfig, mockData)))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("grocery-service listening on :8083")
 	log.Fatal(http.ListenAndServe(":8083", nil))
 }
 
-func initTables(c *sql.DB) {
-	_, err := c.Exec(`
-		CREATE TABLE IF NOT EXISTS groceries (
-			id SERIAL PRIMARY KEY,
-			item VARCHAR(100),
-			price DECIMAL(10,2),
-			source VARCHAR(50)
-		);
-	`)
-	if err != nil {
-		log.Fatal("create table:", err)
-	}
-}
-
-func seedMockData(c *sql.DB) {
-	var count int
-	c.QueryRow("SELECT COUNT(*) FROM groceries").Scan(&count)
-	if count > 0 {
-		return
-	}
-
-	items := []struct {
-		item   string
-		price  float64
-		source string
-	}{
-		{"Rice (1kg)", 45.0, "BigBasket"},
-		{"Wheat Flour (1kg)", 40.0, "Blinkit"},
-		{"Cooking Oil (1L)", 150.0, "BigBasket"},
-		{"Milk (1L)", 55.0, "Blinkit"},
-		{"Vegetables (weekly)", 300.0, "BigBasket"},
-		{"Lentils (1kg)", 80.0, "Blinkit"},
-		{"Sugar (1kg)", 42.0, "BigBasket"},
-		{"Tea/Coffee", 120.0, "Blinkit"},
-	}
-
-	for _, it := range items {
-		c.Exec(`INSERT INTO groceries (item, price, source) VALUES ($1, $2, $3)`,
-			it.item, it.price, it.source)
-	}
+// mockData reloads the staple basket with a quote from every vendor and a year of monthly
+// history behind each. Truncating groceries also clears the old price history. All the
+// generated prices are marked synthetic.
+var mockData = seed.Reset{
+	Tables: []string{"groceries"},
+	Fill: func(tx *sql.Tx, _ seed.Config) (int, error) {
+		items := seed.Groceries()
+		for _, it := range items {
+			var id int
+			if err := tx.QueryRow(`INSERT INTO groceries (item, price, source, unit, adult_weekly_qty, child_weekly_qty)
+				VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+				it.Item, it.Price, it.Source, it.Unit, it.AdultWeeklyQty, it.ChildWeeklyQty).Scan(&id); err != nil {
+				return 0, err
+			}
+			quotes, err := seedVendorPrices(tx, id, it)
+			if err != nil {
+				return 0, err
+			}
+			if err := seedPriceHistory(tx, id, it, quotes); err != nil {
+				return 0, err
+			}
+		}
+		return len(items), nil
+	},
 }
 
 func handleItems(w http.ResponseWriter, r *http.Request) {
@@ -79,30 +84,21 @@ func handleItems(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := conn.Query("SELECT item, price, source FROM groceries ORDER BY price DESC")
+	list, err := loadItems(conn)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	var list []models.GroceryItem
 	var total float64
-	for rows.Next() {
-		var g models.GroceryItem
-		err := rows.Scan(&g.Item, &g.Price, &g.Source)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		list = append(list, g)
+	for _, g := range list {
 		total += g.Price
 	}
 
-	monthlyEstimate := total * 4.3
+	// The estimate is one adult's basket; /basket personalises it for a household.
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"items":            list,
 		"total_basket":     total,
-		"monthly_estimate": monthlyEstimate,
+		"monthly_estimate": buildBasket(list, 1, 0).MonthlyTotal,
 	})
 }
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRollingWindow = 3
+	maxRollingWindow     = 24
+	// stableSlopePerYear is the trend slope, in percentage points a year, below which a
+	// category's rate is described as stable.
+	stableSlopePerYear = 0.5
+	// sharpSlopePerYear is the slope above which a rise or fall is described as sharp.
+	sharpSlopePerYear = 2.0
+)
+
+// ratePoint is one category's rate for a month, with its changes in percentage points on
+// the month before and the same month a year before when those months have data, and the
+// mean rate over the rolling window ending at it.
+type ratePoint struct {
+	Period     string   `json:"period"`
+	Month      string   `json:"month"`
+	Rate       float64  `json:"rate"`
+	MoMChange  *float64 `json:"mom_change,omitempty"`
+	YoYChange  *float64 `json:"yoy_change,omitempty"`
+	RollingAvg float64  `json:"rolling_avg"`
+
+	at time.Time
+}
+
+// extreme is the month a category's rate was lowest or highest.
+type extreme struct {
+	Month string  `json:"month"`
+	Rate  float64 `json:"rate"`
+}
+
+// categoryTrend summarises one category over a range of months. Slope is the least-squares
+// change in the rate per month; Direction is rising, falling or stable by how far the
+// slope moves the rate in a year, and Trend describes it in words.
+type categoryTrend struct {
+	Category      string      `json:"category"`
+	Months        int         `json:"months"`
+	From          string      `json:"from"`
+	To            string      `json:"to"`
+	Latest        float64     `json:"latest"`
+	MoMChange     *float64    `json:"mom_change,omitempty"`
+	YoYChange     *float64    `json:"yoy_change,omitempty"`
+	RollingAvg    float64     `json:"rolling_avg"`
+	Average       float64     `json:"average"`
+	Min           extreme     `json:"min"`
+	Max           extreme     `json:"max"`
+	SlopePerMonth float64     `json:"slope_per_month"`
+	Direction     string      `json:"direction"`
+	Trend         string      `json:"trend"`
+	Series        []ratePoint `json:"series"`
+}
+
+// loadSeries reads every rate, optionally for one category, grouped by category and
+// ordered oldest first.
+func loadSeries(c *sql.DB, category string) (map[string][]ratePoint, error) {
+	query := `SELECT period, category, rate FROM inflation_data`
+	var args []interface{}
+	if category != "" {
+		query += ` WHERE LOWER(category) = LOWER($1)`
+		args = append(args, category)
+	}
+	rows, err := c.Query(query+` ORDER BY category, period`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string][]ratePoint{}
+	for rows.Next() {
+		var p ratePoint
+		var cat string
+		if err := rows.Scan(&p.at, &cat, &p.Rate); err != nil {
+			return nil, err
+		}
+		p.Period, p.Month = p.at.Format("2006-01"), p.at.Format("Jan 2006")
+		out[cat] = append(out[cat], p)
+	}
+	return out, rows.Err()
+}
+
+// withChanges fills in each point's month-on-month and year-on-year changes and its
+// rolling average over the window months ending at it. Months missing from the series are
+// skipped, not treated as zero.
+func withChanges(series []ratePoint, window int) []ratePoint {
+	byPeriod := map[time.Time]float64{}
+	for _, p := range series {
+		byPeriod[p.at] = p.Rate
+	}
+	change := func(p ratePoint, monthsBack int) *float64 {
+		prev, ok := byPeriod[p.at.AddDate(0, -monthsBack, 0)]
+		if !ok {
+			return nil
+		}
+		d := round2(p.Rate - prev)
+		return &d
+	}
+
+	out := make([]ratePoint, len(series))
+	for i, p := range series {
+		p.MoMChange, p.YoYChange = change(p, 1), change(p, 12)
+		var sum float64
+		var n int
+		for m := 0; m < window; m++ {
+			if v, ok := byPeriod[p.at.AddDate(0, -m, 0)]; ok {
+				sum += v
+				n++
+			}
+		}
+		p.RollingAvg = round2(sum / float64(n))
+		out[i] = p
+	}
+	return out
+}
+
+// trendOf summarises a category's series, which must not be empty.
+func trendOf(category string, series []ratePoint) categoryTrend {
+	first, last := series[0], series[len(series)-1]
+	t := categoryTrend{
+		Category:   category,
+		Months:     len(series),
+		From:       first.Period,
+		To:         last.Period,
+		Latest:     last.Rate,
+		MoMChange:  last.MoMChange,
+		YoYChange:  last.YoYChange,
+		RollingAvg: last.RollingAvg,
+		Min:        extreme{first.Month, first.Rate},
+		Max:        extreme{first.Month, first.Rate},
+		Series:     series,
+	}
+	var sum float64
+	for _, p := range series {
+		sum += p.Rate
+		if p.Rate < t.Min.Rate {
+			t.Min = extreme{p.Month, p.Rate}
+		}
+		if p.Rate > t.Max.Rate {
+			t.Max = extreme{p.Month, p.Rate}
+		}
+	}
+	t.Average = round2(sum / float64(len(series)))
+	t.SlopePerMonth = math.Round(slope(series)*1e4) / 1e4
+	t.Direction, t.Trend = describe(t)
+	return t
+}
+
+// slope is the least-squares slope of rate against months since the first point, so gaps
+// in the series keep their width.
+func slope(series []ratePoint) float64 {
+	if len(series) < 2 {
+		return 0
+	}
+	x := func(p ratePoint) float64 {
+		y0, m0 := series[0].at.Year(), series[0].at.Month()
+		return float64((p.at.Year()-y0)*12 + int(p.at.Month()-m0))
+	}
+	var mx, my float64
+	for _, p := range series {
+		mx += x(p)
+		my += p.Rate
+	}
+	n := float64(len(series))
+	mx, my = mx/n, my/n
+	var sxy, sxx float64
+	for _, p := range series {
+		dx := x(p) - mx
+		sxy += dx * (p.Rate - my)
+		sxx += dx * dx
+	}
+	if sxx == 0 {
+		return 0
+	}
+	return sxy / sxx
+}
+
+// describe words a category's trend from its slope, range and latest changes.
+func describe(t categoryTrend) (direction, trend string) {
+	last := t.Series[len(t.Series)-1]
+	if t.Months < 3 {
+		return "unknown", fmt.Sprintf("Only %d month(s) of %s inflation data, too few to call a trend. It was %.2f%% in %s.",
+			t.Months, t.Category, t.Latest, last.Month)
+	}
+
+	perYear := t.SlopePerMonth * 12
+	var b strings.Builder
+	switch {
+	case math.Abs(perYear) < stableSlopePerYear:
+		direction = "stable"
+		fmt.Fprintf(&b, "%s inflation has been relatively stable over the past %d months, averaging %.2f%% (%.2f%%–%.2f%%).",
+			t.Category, t.Months, t.Average, t.Min.Rate, t.Max.Rate)
+	default:
+		direction = "rising"
+		if perYear < 0 {
+			direction = "falling"
+		}
+		pace := ""
+		if math.Abs(perYear) >= sharpSlopePerYear {
+			pace = " sharply"
+		}
+		fmt.Fprintf(&b, "%s inflation has been %s%s over the past %d months, by about %.2f points a month, between %.2f%% and %.2f%%.",
+			t.Category, direction, pace, t.Months, math.Abs(t.SlopePerMonth), t.Min.Rate, t.Max.Rate)
+	}
+
+	fmt.Fprintf(&b, " It was %.2f%% in %s", t.Latest, last.Month)
+	var changes []string
+	if t.MoMChange != nil {
+		changes = append(changes, signedPoints(*t.MoMChange)+" on the month")
+	}
+	if t.YoYChange != nil {
+		changes = append(changes, signedPoints(*t.YoYChange)+" on the year")
+	}
+	if len(changes) > 0 {
+		b.WriteString(", " + strings.Join(changes, " and "))
+	}
+	b.WriteString(".")
+	return direction, b.String()
+}
+
+func signedPoints(d float64) string {
+	switch {
+	case d > 0:
+		return fmt.Sprintf("up %.2f points", d)
+	case d < 0:
+		return fmt.Sprintf("down %.2f points", -d)
+	}
+	return "unchanged"
+}
+
+// analyse computes every category's trend over from..to (inclusive months; zero means
+// unbounded). Changes and rolling averages look back past from, so the first months of a
+// range still have them.
+func analyse(series map[string][]ratePoint, from, to time.Time, window int) []categoryTrend {
+	out := []categoryTrend{}
+	for cat, points := range series {
+		var in []ratePoint
+		for _, p := range withChanges(points, window) {
+			if (from.IsZero() || !p.at.Before(from)) && (to.IsZero() || !p.at.After(to)) {
+				in = append(in, p)
+			}
+		}
+		if len(in) > 0 {
+			out = append(out, trendOf(cat, in))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Category < out[j].Category })
+	return out
+}
+
+// handleAnalytics serves GET /analytics: for each category, or only ?category=, the
+// month-on-month and year-on-year change of every month, a rolling average over ?window=
+// months (default 3), the range's average, lowest and highest months, the least-squares
+// trend slope and a description of the trend. ?from= and ?to= (YYYY-MM) bound the months
+// summarised.
+func handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01", v); err != nil {
+			http.Error(w, "from must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01", v); err != nil {
+			http.Error(w, "to must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+	window := defaultRollingWindow
+	if v := q.Get("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRollingWindow {
+			http.Error(w, fmt.Sprintf("window must be 1-%d months", maxRollingWindow), http.StatusBadRequest)
+			return
+		}
+		window = n
+	}
+
+	series, err := loadSeries(conn, q.Get("category"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	trends := analyse(series, from, to, window)
+	if q.Get("category") != "" && len(trends) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no data for category"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window_months": window,
+		"categories":    trends,
+	})
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
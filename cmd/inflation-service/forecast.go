@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultForecastMonths = 6
+	maxForecastMonths     = 24
+	defaultBacktestMonths = 3
+	// minFitMonths is the shortest history a model is fitted to.
+	minFitMonths = 4
+
+	z80 = 1.2816
+	z95 = 1.96
+)
+
+// holt is Holt's linear exponential smoothing with a damped trend: Alpha smooths the level,
+// Beta the trend, and Phi shrinks the trend each month ahead (1 is undamped).
+type holt struct {
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+	Phi   float64 `json:"phi"`
+}
+
+// fitted is a holt model run over a series: its final level and trend, and the standard
+// deviation of its one-month-ahead errors.
+type fitted struct {
+	holt
+	level, trend float64
+	Sigma        float64 `json:"sigma"`
+	sse          float64
+}
+
+// run smooths y, which must have at least two values. The first two values set the level
+// and trend, so errors are counted from the third.
+func (m holt) run(y []float64) fitted {
+	f := fitted{holt: m, level: y[0], trend: y[1] - y[0]}
+	n := 0
+	for t := 1; t < len(y); t++ {
+		pred := f.level + m.Phi*f.trend
+		if t > 1 {
+			e := y[t] - pred
+			f.sse += e * e
+			n++
+		}
+		level := m.Alpha*y[t] + (1-m.Alpha)*pred
+		f.trend = m.Beta*(level-f.level) + (1-m.Beta)*m.Phi*f.trend
+		f.level = level
+	}
+	if n > 0 {
+		f.Sigma = math.Sqrt(f.sse / float64(n))
+	}
+	return f
+}
+
+// fitHolt grid-searches the smoothing parameters for the smallest one-month-ahead squared
+// error over y.
+func fitHolt(y []float64) fitted {
+	var best fitted
+	found := false
+	for _, phi := range []float64{0.8, 0.9, 0.98, 1} {
+		for a := 1; a <= 9; a++ {
+			for b := 1; b <= 9; b++ {
+				f := holt{Alpha: float64(a) / 10, Beta: float64(b) / 10, Phi: phi}.run(y)
+				if !found || f.sse < best.sse {
+					best, found = f, true
+				}
+			}
+		}
+	}
+	return best
+}
+
+// forecastPoint is the predicted rate h months past the data, with 80% and 95% bands.
+type forecastPoint struct {
+	Period  string  `json:"period"`
+	Month   string  `json:"month"`
+	Rate    float64 `json:"rate"`
+	Lower80 float64 `json:"lower_80"`
+	Upper80 float64 `json:"upper_80"`
+	Lower95 float64 `json:"lower_95"`
+	Upper95 float64 `json:"upper_95"`
+
+	at time.Time
+}
+
+// ahead forecasts the months after last. The h-month variance is the additive-error
+// Holt variance σ²(1 + Σ_{j<h} α²(1 + βφ_j)²), with φ_j = φ + … + φ^j, so the bands widen
+// with the horizon.
+func (f fitted) ahead(last time.Time, months int) []forecastPoint {
+	out := make([]forecastPoint, 0, months)
+	damp, variance := 0.0, 1.0
+	for h := 1; h <= months; h++ {
+		if h > 1 {
+			c := f.Alpha * (1 + f.Beta*damp)
+			variance += c * c
+		}
+		damp += math.Pow(f.Phi, float64(h))
+		rate := f.level + damp*f.trend
+		sd := f.Sigma * math.Sqrt(variance)
+		at := last.AddDate(0, h, 0)
+		out = append(out, forecastPoint{
+			Period: at.Format("2006-01"), Month: at.Format("Jan 2006"), Rate: round2(rate),
+			Lower80: round2(rate - z80*sd), Upper80: round2(rate + z80*sd),
+			Lower95: round2(rate - z95*sd), Upper95: round2(rate + z95*sd),
+			at: at,
+		})
+	}
+	return out
+}
+
+// monthly lays a series out one value per month from its first to its last, filling any
+// missing months by linear interpolation between their neighbours.
+func monthly(series []ratePoint) []float64 {
+	var out []float64
+	for i, p := range series {
+		if i > 0 {
+			prev := series[i-1]
+			gap := monthsBetween(prev.at, p.at)
+			for k := 1; k < gap; k++ {
+				out = append(out, prev.Rate+(p.Rate-prev.Rate)*float64(k)/float64(gap))
+			}
+		}
+		out = append(out, p.Rate)
+	}
+	return out
+}
+
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+}
+
+// categoryForecast is one category's fitted model and its forecast.
+type categoryForecast struct {
+	Category      string          `json:"category"`
+	HistoryMonths int             `json:"history_months"`
+	LastPeriod    string          `json:"last_period"`
+	LastRate      float64         `json:"last_rate"`
+	Model         fitted          `json:"model"`
+	Forecast      []forecastPoint `json:"forecast"`
+}
+
+// errTooShort reports a category with too little history to fit.
+type errTooShort struct {
+	category   string
+	have, need int
+}
+
+func (e errTooShort) Error() string {
+	return fmt.Sprintf("%s has %d month(s) of data; at least %d needed", e.category, e.have, e.need)
+}
+
+func forecastCategory(category string, series []ratePoint, months int) (categoryForecast, error) {
+	y := monthly(series)
+	if len(y) < minFitMonths {
+		return categoryForecast{}, errTooShort{category, len(y), minFitMonths}
+	}
+	model := fitHolt(y)
+	model.Sigma = math.Round(model.Sigma*1e4) / 1e4
+	last := series[len(series)-1]
+	return categoryForecast{
+		Category:      category,
+		HistoryMonths: len(y),
+		LastPeriod:    last.Period,
+		LastRate:      last.Rate,
+		Model:         model,
+		Forecast:      model.ahead(last.at, months),
+	}, nil
+}
+
+// backtestResult scores a model fitted without the last Months months against the rates
+// recorded for them.
+// MAPE is the mean absolute percentage error of the forecast; NaiveMAPE is that of
+// carrying the last fitted month's rate forward, as a baseline the model should beat.
+// Coverage95 is the share of actual rates inside the 95% band.
+type backtestResult struct {
+	Category    string          `json:"category"`
+	Months      int             `json:"months"`
+	TrainMonths int             `json:"train_months"`
+	Model       fitted          `json:"model"`
+	MAPE        float64         `json:"mape"`
+	MAE         float64         `json:"mae"`
+	NaiveMAPE   float64         `json:"naive_mape"`
+	Coverage95  float64         `json:"coverage_95"`
+	Points      []backtestPoint `json:"points"`
+}
+
+type backtestPoint struct {
+	forecastPoint
+	Actual float64 `json:"actual"`
+	Error  float64 `json:"error"`
+}
+
+// backtestCategory holds out the last months months of series. With months 0 it holds out
+// up to defaultBacktestMonths, leaving at least minFitMonths to fit.
+func backtestCategory(category string, series []ratePoint, months int) (backtestResult, error) {
+	y := monthly(series)
+	if months == 0 {
+		months = defaultBacktestMonths
+		if len(y)-months < minFitMonths {
+			months = len(y) - minFitMonths
+		}
+	}
+	if months < 1 || len(y)-months < minFitMonths {
+		return backtestResult{}, errTooShort{category, len(y), minFitMonths + max(months, 1)}
+	}
+
+	train, test := y[:len(y)-months], y[len(y)-months:]
+	model := fitHolt(train)
+	model.Sigma = math.Round(model.Sigma*1e4) / 1e4
+	cut := series[0].at.AddDate(0, len(train)-1, 0)
+	res := backtestResult{Category: category, Months: months, TrainMonths: len(train), Model: model}
+
+	recorded := map[time.Time]bool{}
+	for _, p := range series {
+		recorded[p.at] = true
+	}
+	var ape, ae, naive float64
+	n, scored, inside := 0, 0, 0
+	for i, fp := range model.ahead(cut, months) {
+		// Months filled in by interpolation are forecast but not scored.
+		if !recorded[fp.at] {
+			continue
+		}
+		actual := test[i]
+		scored++
+		p := backtestPoint{forecastPoint: fp, Actual: round2(actual), Error: round2(actual - fp.Rate)}
+		res.Points = append(res.Points, p)
+		ae += math.Abs(actual - fp.Rate)
+		if actual >= fp.Lower95 && actual <= fp.Upper95 {
+			inside++
+		}
+		if actual != 0 {
+			ape += math.Abs((actual - fp.Rate) / actual)
+			naive += math.Abs((actual - train[len(train)-1]) / actual)
+			n++
+		}
+	}
+	if n > 0 {
+		res.MAPE = round2(ape / float64(n) * 100)
+		res.NaiveMAPE = round2(naive / float64(n) * 100)
+	}
+	res.MAE = round2(ae / float64(scored))
+	res.Coverage95 = round2(float64(inside) / float64(scored) * 100)
+	return res, nil
+}
+
+// parseMonthsParam reads ?months= as 1 to maxForecastMonths, or def when absent.
+func parseMonthsParam(r *http.Request, def int) (int, error) {
+	v := r.URL.Query().Get("months")
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxForecastMonths {
+		return 0, fmt.Errorf("months must be 1-%d", maxForecastMonths)
+	}
+	return n, nil
+}
+
+// seriesFor loads ?category=, or every category, and writes a 404 when the category has
+// no data.
+func seriesFor(w http.ResponseWriter, r *http.Request) ([]string, map[string][]ratePoint, bool) {
+	category := r.URL.Query().Get("category")
+	series, err := loadSeries(conn, category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if category != "" && len(series) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no data for category"})
+		return nil, nil, false
+	}
+	cats := make([]string, 0, len(series))
+	for cat := range series {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+	return cats, series, true
+}
+
+// writeTooShort answers 422 naming the categories that could not be fitted.
+func writeTooShort(w http.ResponseWriter, errs []string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]string{"error": "not enough history: " + strings.Join(errs, "; ")})
+}
+
+// handleForecast serves GET /forecast: the next ?months= months (default 6, at most 24) of
+// ?category=, or of every category, from a damped Holt model fitted to its monthly rates,
+// with 80% and 95% bands. Categories with under minFitMonths months are listed in
+// skipped; asking for one by name is a 422.
+func handleForecast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	months, err := parseMonthsParam(r, defaultForecastMonths)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cats, series, ok := seriesFor(w, r)
+	if !ok {
+		return
+	}
+
+	forecasts := []categoryForecast{}
+	skipped := []string{}
+	for _, cat := range cats {
+		f, err := forecastCategory(cat, series[cat], months)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		forecasts = append(forecasts, f)
+	}
+	if len(forecasts) == 0 && len(skipped) > 0 {
+		writeTooShort(w, skipped)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"months":    months,
+		"forecasts": forecasts,
+		"skipped":   skipped,
+	})
+}
+
+// handleBacktest serves GET /backtest: for ?category=, or every category, the model is
+// fitted without the last ?months= months (default up to 3) and its forecast of them is
+// scored by MAPE against what was recorded.
+func handleBacktest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	months, err := parseMonthsParam(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cats, series, ok := seriesFor(w, r)
+	if !ok {
+		return
+	}
+
+	results := []backtestResult{}
+	skipped := []string{}
+	for _, cat := range cats {
+		res, err := backtestCategory(cat, series[cat], months)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		results = append(results, res)
+	}
+	if len(results) == 0 && len(skipped) > 0 {
+		writeTooShort(w, skipped)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"skipped": skipped,
+	})
+}
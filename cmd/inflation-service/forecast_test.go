@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// seriesOf builds a monthly series from January 2023, one point per rate. A NaN rate
+// leaves that month out.
+func seriesOf(rates ...float64) []ratePoint {
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var out []ratePoint
+	for i, r := range rates {
+		if math.IsNaN(r) {
+			continue
+		}
+		at := start.AddDate(0, i, 0)
+		out = append(out, ratePoint{Period: at.Format("2006-01"), Month: at.Format("Jan 2006"), Rate: r, at: at})
+	}
+	return out
+}
+
+// repeat is n copies of v.
+func repeat(v float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func TestBacktestMAPE(t *testing.T) {
+	linear := make([]float64, 12)
+	for i := range linear {
+		linear[i] = 2 + 0.25*float64(i)
+	}
+	gap := math.NaN()
+
+	tests := []struct {
+		name       string
+		rates      []float64
+		months     int
+		wantMonths int
+		mape       float64
+		naive      float64
+		mae        float64
+		points     int
+	}{
+		{
+			// A straight line is fitted exactly by the undamped model; carrying the last
+			// rate forward misses by 0.25, 0.5 and 0.75 points.
+			name:  "linear trend",
+			rates: linear, months: 3, wantMonths: 3,
+			mape: 0, naive: round2((0.25/4.25 + 0.5/4.5 + 0.75/4.75) / 3 * 100), mae: 0, points: 3,
+		},
+		{
+			name:  "flat",
+			rates: repeat(5, 10), months: 2, wantMonths: 2,
+			mape: 0, naive: 0, mae: 0, points: 2,
+		},
+		{
+			// A flat history forecasts 5; the held-out months jump to 6.
+			name:  "level shift",
+			rates: append(repeat(5, 8), 6, 6), months: 2, wantMonths: 2,
+			mape: round2(1.0 / 6 * 100), naive: round2(1.0 / 6 * 100), mae: 1, points: 2,
+		},
+		{
+			// A zero actual counts towards MAE but can't be scored as a percentage.
+			name:  "zero actual skipped",
+			rates: append(repeat(5, 8), 0, 10), months: 2, wantMonths: 2,
+			mape: 50, naive: 50, mae: 5, points: 2,
+		},
+		{
+			// Month 9 is missing and interpolated to 5.5: it is forecast but only
+			// month 10 is scored.
+			name:  "interpolated month not scored",
+			rates: append(repeat(5, 8), gap, 6), months: 2, wantMonths: 2,
+			mape: round2(1.0 / 6 * 100), naive: round2(1.0 / 6 * 100), mae: 1, points: 1,
+		},
+		{
+			// months 0 holds out 3, or fewer to keep minFitMonths to fit.
+			name:  "default holds out what it can",
+			rates: repeat(5, 6), months: 0, wantMonths: 2,
+			points: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := backtestCategory("Test", seriesOf(tt.rates...), tt.months)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Months != tt.wantMonths || len(res.Points) != tt.points {
+				t.Fatalf("held out %d months, scored %d; want %d and %d", res.Months, len(res.Points), tt.wantMonths, tt.points)
+			}
+			if res.MAPE != tt.mape || res.NaiveMAPE != tt.naive || res.MAE != tt.mae {
+				t.Errorf("MAPE %v, naive %v, MAE %v; want %v, %v, %v", res.MAPE, res.NaiveMAPE, res.MAE, tt.mape, tt.naive, tt.mae)
+			}
+		})
+	}
+}
+
+func TestBacktestTooShort(t *testing.T) {
+	tests := []struct {
+		name   string
+		months int
+		rates  []float64
+	}{
+		{"default with minFitMonths of data", 0, repeat(5, minFitMonths)},
+		{"holds out too many", 2, repeat(5, minFitMonths+1)},
+	}
+	for _, tt := range tests {
+		_, err := backtestCategory("Test", seriesOf(tt.rates...), tt.months)
+		var short errTooShort
+		if !errors.As(err, &short) {
+			t.Errorf("%s: error = %v, want errTooShort", tt.name, err)
+		}
+	}
+}
+
+func TestForecastBandsWiden(t *testing.T) {
+	rates := []float64{4.1, 4.4, 4.2, 4.8, 5.1, 4.9, 5.4, 5.2, 5.9, 6.1}
+	f, err := forecastCategory("Test", seriesOf(rates...), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range f.Forecast {
+		if !(p.Lower95 <= p.Lower80 && p.Lower80 <= p.Rate && p.Rate <= p.Upper80 && p.Upper80 <= p.Upper95) {
+			t.Errorf("month %d: bands out of order: %+v", i+1, p)
+		}
+		if i > 0 && p.Upper95-p.Lower95 < f.Forecast[i-1].Upper95-f.Forecast[i-1].Lower95 {
+			t.Errorf("month %d: 95%% band narrower than the month before", i+1)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// inflationImport imports inflation_data rows: month ("Jan 2025" or "2025-01"), category and
+// rate (percent). A row for an existing month and category replaces its rate.
+var inflationImport = importer.Spec[models.InflationRecord]{
+	Parse: func(row *importer.Row) models.InflationRecord {
+		rec := models.InflationRecord{
+			Category: row.String("category", true),
+			Rate:     row.Float("rate"),
+		}
+		if m := row.String("month", true); m != "" {
+			t, err := time.Parse("Jan 2006", m)
+			if err != nil {
+				t, err = time.Parse("2006-01", m)
+			}
+			if err != nil {
+				row.Errorf("month", `want "Jan 2006" or YYYY-MM`)
+			}
+			rec.Period, rec.Month = t, t.Format("Jan 2006")
+		}
+		if len(rec.Category) > 50 {
+			row.Errorf("category", "at most 50 characters")
+		}
+		if rec.Rate < -50 || rec.Rate > 100 {
+			row.Errorf("rate", "must be between -50 and 100")
+		}
+		return rec
+	},
+	Insert: func(tx *sql.Tx, recs []models.InflationRecord) error {
+		for _, rec := range recs {
+			res, err := tx.Exec(`UPDATE inflation_data SET rate = $3 WHERE period = $1 AND LOWER(category) = LOWER($2)`,
+				rec.Period, rec.Category, rec.Rate)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				continue
+			}
+			_, err = tx.Exec(`INSERT INTO inflation_data (period, rate, category) VALUES ($1, $2, $3)`,
+				rec.Period, rec.Rate, rec.Category)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -3,16 +3,27 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"log"
-	"math/rand"
 	"net/http"
+	"time"
 
+	"rent-cost-analyzer/internal/auth"
 	"rent-cost-analyzer/internal/db"
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/internal/seed"
 )
 
 var conn *sql.DB
 
 func main() {
+	seedConfig := seed.FromEnv()
+	seedConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
 	c, err := db.Open()
 	if err != nil {
 		log.Fatal("db open:", err)
@@ -20,48 +31,42 @@ func main() {
 	defer c.Close()
 	conn = c
 
-	initTables(conn)
-	seedMockData(conn)
+	if n, err := db.MigrateUp(conn, "inflation"); err != nil {
+		log.Fatal("migrate:", err)
+	} else if n > 0 {
+		log.Printf("applied %d inflation migration(s)", n)
+	}
+	if _, err := seed.IfEmpty(conn, mockData, seedConfig); err != nil {
+		log.Println("seed inflation data:", err)
+	}
 
-	http.HandleFunc("/data", handleData)
-	http.HandleFunc("/summary", handleSummary)
+	http.HandleFunc("/data", auth.Require(auth.ScopeRead, handleData))
+	http.HandleFunc("/summary", auth.Require(auth.ScopeRead, handleSummary))
+	http.HandleFunc("/analytics", auth.Require(auth.ScopeRead, handleAnalytics))
+	http.HandleFunc("/forecast", auth.Require(auth.ScopeRead, handleForecast))
+	http.HandleFunc("/backtest", auth.Require(auth.ScopeRead, handleBacktest))
+	http.HandleFunc("/latest", auth.Require(auth.ScopeRead, handleLatest))
+	http.HandleFunc("/import", auth.Require(auth.ScopeWrite, importer.Handler(conn, inflationImport)))
+	http.HandleFunc("/admin/reset-and-seed", auth.Require(auth.ScopeAdmin, seed.Handler(conn, seedConfig, mockData)))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("inflation-service listening on :8085")
 	log.Fatal(http.ListenAndServe(":8085", nil))
 }
 
-func initTables(c *sql.DB) {
-	_, err := c.Exec(`
-		CREATE TABLE IF NOT EXISTS inflation_data (
-			id SERIAL PRIMARY KEY,
-			month VARCHAR(20),
-			rate DECIMAL(5,2),
-			category VARCHAR(50)
-		);
-	`)
-	if err != nil {
-		log.Fatal("create table:", err)
-	}
-}
-
-func seedMockData(c *sql.DB) {
-	var count int
-	c.QueryRow("SELECT COUNT(*) FROM inflation_data").Scan(&count)
-	if count > 0 {
-		return
-	}
-
-	months := []string{"Jan 2025", "Dec 2024", "Nov 2024", "Oct 2024", "Sep 2024", "Aug 2024"}
-	categories := []string{"Food", "Housing", "Transport", "Overall"}
-
-	for _, month := range months {
-		for _, category := range categories {
-			rate := 5.5 + rand.Float64()*2.5
-			c.Exec(`INSERT INTO inflation_data (month, rate, category) VALUES ($1, $2, $3)`,
-				month, rate, category)
+// mockData regenerates monthly rates for every category.
+var mockData = seed.Reset{
+	Tables: []string{"inflation_data"},
+	Fill: func(tx *sql.Tx, c seed.Config) (int, error) {
+		records := seed.Inflation(c)
+		for _, rec := range records {
+			if _, err := tx.Exec(`INSERT INTO inflation_data (period, rate, category) VALUES ($1, $2, $3)`,
+				rec.Period, rec.Rate, rec.Category); err != nil {
+				return 0, err
+			}
 		}
-	}
+		return len(records), nil
+	},
 }
 
 func handleData(w http.ResponseWriter, r *http.Request) {
@@ -72,9 +77,9 @@ func handleData(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	rows, err := conn.Query(`
-		SELECT month, category, rate 
+		SELECT period, category, rate 
 		FROM inflation_data 
-		ORDER BY month DESC, category
+		ORDER BY period DESC, category
 	`)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -83,6 +88,7 @@ func handleData(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	type row struct {
+		Period   string  `json:"period"`
 		Month    string  `json:"month"`
 		Category string  `json:"category"`
 		Rate     float64 `json:"rate"`
@@ -91,13 +97,20 @@ func handleData(w http.ResponseWriter, r *http.Request) {
 	var list []row
 	for rows.Next() {
 		var r row
-		rows.Scan(&r.Month, &r.Category, &r.Rate)
+		var period time.Time
+		rows.Scan(&period, &r.Category, &r.Rate)
+		r.Period, r.Month = period.Format("2006-01"), period.Format("Jan 2006")
 		list = append(list, r)
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{"data": list})
 }
 
+// summaryMonths is how many recent months /summary describes the Overall trend over.
+const summaryMonths = 6
+
+// handleSummary serves GET /summary: the average Overall rate across all months, and the
+// Overall trend over the latest summaryMonths months as /analytics computes it.
 func handleSummary(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -105,15 +118,74 @@ func handleSummary(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 
-	var avgRate float64
-	err := conn.QueryRow("SELECT AVG(rate) FROM inflation_data WHERE category = 'Overall'").Scan(&avgRate)
+	var avgRate sql.NullFloat64
+	err := conn.QueryRow("SELECT AVG(rate) FROM inflation_data WHERE LOWER(category) = LOWER($1)", "Overall").Scan(&avgRate)
 	if err != nil && err != sql.ErrNoRows {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	series, err := loadSeries(conn, "Overall")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"average_overall_inflation": round2(avgRate.Float64),
+		"trend":                     "No Overall inflation data yet",
+	}
+	for _, points := range series {
+		from := points[len(points)-1].at.AddDate(0, 1-summaryMonths, 0)
+		t := analyse(series, from, time.Time{}, defaultRollingWindow)[0]
+		resp["trend"] = t.Trend
+		resp["direction"] = t.Direction
+		resp["slope_per_month"] = t.SlopePerMonth
+		resp["latest"] = t.Latest
+		resp["mom_change"] = t.MoMChange
+		resp["yoy_change"] = t.YoYChange
+		resp["from"], resp["to"] = t.From, t.To
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleLatest serves GET /latest: each category's most recent rate, keyed by category.
+func handleLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := conn.Query(`
+		SELECT DISTINCT ON (category) period, category, rate
+		FROM inflation_data
+		ORDER BY category, period DESC
+	`)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"average_overall_inflation": avgRate,
-		"trend":                     "Inflation has been relatively stable over the past 6 months",
-	})
+	type latestRate struct {
+		Month string  `json:"month"`
+		Rate  float64 `json:"rate"`
+	}
+	latest := map[string]latestRate{}
+	for rows.Next() {
+		var period time.Time
+		var cat string
+		var rec latestRate
+		if err := rows.Scan(&period, &cat, &rec.Rate); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rec.Month = period.Format("Jan 2006")
+		latest[cat] = rec
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"rates": latest})
 }
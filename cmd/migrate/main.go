@@ -0,0 +1,85 @@
+// Command migrate applies, reverts and reports the schema migrations in internal/db.
+//
+//	migrate [-service name] up
+//	migrate -service name [-steps n] down
+//	migrate [-service name] status
+//
+// Without -service, up and status cover every service. down always needs -service.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"rent-cost-analyzer/internal/db"
+)
+
+func main() {
+	service := flag.String("service", "", "service to migrate (default: all for up/status)")
+	steps := flag.Int("steps", 1, "number of migrations to revert with down")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-service name] [-steps n] up|down|status")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c, err := db.Open()
+	if err != nil {
+		log.Fatal("db open:", err)
+	}
+	defer c.Close()
+
+	services := []string{*service}
+	if *service == "" {
+		if services, err = db.MigrationServices(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch cmd := flag.Arg(0); cmd {
+	case "up":
+		for _, svc := range services {
+			n, err := db.MigrateUp(c, svc)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%-10s %d migration(s) applied\n", svc, n)
+		}
+	case "down":
+		if *service == "" {
+			log.Fatal("down needs -service")
+		}
+		if *steps < 1 {
+			log.Fatal("-steps must be at least 1")
+		}
+		n, err := db.MigrateDown(c, *service, *steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%-10s %d migration(s) reverted\n", *service, n)
+	case "status":
+		for _, svc := range services {
+			states, err := db.MigrationStatus(c, svc)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, s := range states {
+				applied := "pending"
+				if s.Applied() {
+					applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%-10s %04d  %-28s %s\n", svc, s.Version, s.Name, applied)
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
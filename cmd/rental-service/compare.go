@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rent-cost-analyzer/internal/auth"
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultUserServiceURL      = "http://localhost:8081"
+	defaultGroceryServiceURL   = "http://localhost:8083"
+	defaultTransportServiceURL = "http://localhost:8084"
+	defaultInflationServiceURL = "http://localhost:8085"
+	maxCompare                 = 10
+	maxHousehold               = 20
+	// commuteWorkers bounds the concurrent /route calls one comparison makes.
+	commuteWorkers = 4
+	// commuteDeadline bounds all of a comparison's /route calls together.
+	commuteDeadline = 10 * time.Second
+)
+
+var serviceClient = &http.Client{Timeout: 5 * time.Second}
+
+// serviceURL is env's value without a trailing slash, or def when it is unset.
+func serviceURL(env, def string) string {
+	if u := os.Getenv(env); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return def
+}
+
+// statusError is an error with the status to answer it with: a bad parameter or a failed
+// call to another service.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+// getJSON fetches u with the caller's token and decodes a 200 response into v. A 404
+// keeps its status and the service's message; anything else is a 502.
+func getJSON(r *http.Request, service, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	auth.Forward(r, req)
+
+	resp, err := serviceClient.Do(req)
+	if err != nil {
+		return &statusError{http.StatusBadGateway, fmt.Errorf("%s: %w", service, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) != nil || body.Error == "" {
+			body.Error = resp.Status
+		}
+		status := http.StatusBadGateway
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			status = resp.StatusCode
+		}
+		return &statusError{status, fmt.Errorf("%s: %s", service, body.Error)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &statusError{http.StatusBadGateway, fmt.Errorf("%s: %w", service, err)}
+	}
+	return nil
+}
+
+// writeCompareError answers with a statusError's status, 404 for an unknown locality,
+// or 500.
+func writeCompareError(w http.ResponseWriter, err error) {
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		w.WriteHeader(se.status)
+		json.NewEncoder(w).Encode(map[string]string{"error": se.Error()})
+	case errors.Is(err, locality.ErrUnknown):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// costContext is who monthly costs are priced for: the household size, where they
+// commute to (none when Workplace is empty), the one-way commute in km to price when there
+// is no workplace (0 when unknown) and their income (0 when unknown). home is the profile's
+// preferred locality, if a profile was given.
+type costContext struct {
+	FamilySize      int     `json:"family_size"`
+	Workplace       string  `json:"workplace,omitempty"`
+	CommuteDistance float64 `json:"commute_distance,omitempty"`
+	Income          float64 `json:"income,omitempty"`
+	home            string
+}
+
+// parseCostContext reads ?user_id= for the profile's family size, workplace, commute
+// distance and income, then lets ?family_size=, ?workplace=, ?commute_distance= and
+// ?income= override them. The household defaults to one person. A given workplace must be
+// a registered locality.
+func parseCostContext(r *http.Request) (costContext, error) {
+	q := r.URL.Query()
+	c := costContext{FamilySize: 1}
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return c, &statusError{http.StatusBadRequest, errors.New("user_id must be a positive integer")}
+		}
+		var u models.UserProfile
+		if err := getJSON(r, "user-service", fmt.Sprintf("%s/users/%d", serviceURL("USER_SERVICE_URL", defaultUserServiceURL), id), &u); err != nil {
+			return c, err
+		}
+		c = costContext{FamilySize: u.FamilySize, Workplace: u.Workplace, CommuteDistance: u.CommuteDistance,
+			Income: u.Income, home: u.PreferredLocale}
+	}
+	if v := q.Get("family_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxHousehold {
+			return c, &statusError{http.StatusBadRequest, fmt.Errorf("family_size must be 1-%d", maxHousehold)}
+		}
+		c.FamilySize = n
+	}
+	if v := q.Get("workplace"); v != "" {
+		c.Workplace = v
+	}
+	if v := q.Get("commute_distance"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return c, &statusError{http.StatusBadRequest, errors.New("invalid commute_distance")}
+		}
+		c.CommuteDistance = f
+	}
+	if v := q.Get("income"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			return c, &statusError{http.StatusBadRequest, errors.New("invalid income")}
+		}
+		c.Income = f
+	}
+	if c.FamilySize < 1 {
+		c.FamilySize = 1
+	}
+	if c.Workplace != "" {
+		l, err := localities.Resolve(c.Workplace)
+		if err != nil {
+			return c, err
+		}
+		c.Workplace = l.Name
+	}
+	return c, nil
+}
+
+// commute is the cheapest practical way to work: over the route to the workplace from
+// transport-service, or over the profile's commute distance when there is no workplace.
+// Basis says which.
+type commute struct {
+	Mode        fare.Mode `json:"mode"`
+	Fare        float64   `json:"fare"`
+	TimeMin     float64   `json:"time_min"`
+	MonthlyCost float64   `json:"monthly_cost"`
+	Legs        int       `json:"legs"`
+	Basis       string    `json:"basis"`
+}
+
+// groceryCost is the household's monthly basket from grocery-service. Grocery prices do
+// not vary by locality, so one basket prices every locality.
+func groceryCost(r *http.Request, c costContext) (float64, error) {
+	var basket models.GroceryBasket
+	u := fmt.Sprintf("%s/basket?family_size=%d", serviceURL("GROCERY_SERVICE_URL", defaultGroceryServiceURL), c.FamilySize)
+	if err := getJSON(r, "grocery-service", u, &basket); err != nil {
+		return 0, err
+	}
+	return basket.MonthlyTotal, nil
+}
+
+// commuteFrom asks transport-service for the journey from a locality to the workplace and
+// returns its cheapest mode, or nil if no route connects them.
+func commuteFrom(r *http.Request, from, workplace string) (*commute, error) {
+	var route struct {
+		Found    bool              `json:"found"`
+		Legs     []json.RawMessage `json:"legs"`
+		Modes    []fare.Quote      `json:"modes"`
+		Cheapest fare.Mode         `json:"cheapest"`
+	}
+	u := fmt.Sprintf("%s/route?from=%s&to=%s", serviceURL("TRANSPORT_SERVICE_URL", defaultTransportServiceURL),
+		url.QueryEscape(from), url.QueryEscape(workplace))
+	if err := getJSON(r, "transport-service", u, &route); err != nil {
+		return nil, err
+	}
+	if !route.Found {
+		return nil, nil
+	}
+	for _, q := range route.Modes {
+		if q.Mode == route.Cheapest {
+			return &commute{Mode: q.Mode, Fare: q.Fare, TimeMin: q.TimeMin, MonthlyCost: q.MonthlyCost,
+				Legs: len(route.Legs), Basis: "route to workplace"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// commutesFrom runs commuteFrom for every locality, at most commuteWorkers at a time and
+// all under one commuteDeadline, returning the commutes in names' order. The first error
+// cancels the calls still running; running out of time answers 504.
+func commutesFrom(r *http.Request, names []string, workplace string) ([]*commute, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), commuteDeadline)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	out := make([]*commute, len(names))
+	sem := make(chan struct{}, commuteWorkers)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+			c, err := commuteFrom(r, name, workplace)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			out[i] = c
+		}(i, name)
+	}
+	wg.Wait()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, &statusError{http.StatusGatewayTimeout,
+			fmt.Errorf("transport-service: routes not found within %s", commuteDeadline)}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// commuteOver prices a one-way trip of distKm as a single bus leg at the BCLL slab fare
+// and returns its cheapest mode. It does not depend on the locality.
+func commuteOver(distKm float64) *commute {
+	leg := models.TransportRoute{Distance: distKm, Fare: fare.BusFare(distKm)}
+	q := fare.Cheapest(fare.Quotes([]models.TransportRoute{leg}, fare.DefaultParams(), fare.AllDay))
+	return &commute{Mode: q.Mode, Fare: q.Fare, TimeMin: q.TimeMin, MonthlyCost: q.MonthlyCost,
+		Legs: 1, Basis: "commute distance"}
+}
+
+// costDelta is how much more a locality costs than the cheapest one, per component.
+type costDelta struct {
+	Rent      float64 `json:"rent"`
+	Groceries float64 `json:"groceries"`
+	Transport float64 `json:"transport"`
+	Total     float64 `json:"total"`
+}
+
+// localityCost is one locality's monthly cost. It is incomplete, and ranked after every
+// complete one, when the locality has no listings, or the commute can't be priced: no
+// route to the workplace, or neither a workplace nor a commute distance. Missing says which.
+type localityCost struct {
+	Rank     int                 `json:"rank"`
+	Locality string              `json:"locality"`
+	Listings int                 `json:"listings"`
+	Analysis models.CostAnalysis `json:"analysis"`
+	Commute  *commute            `json:"commute,omitempty"`
+	Delta    costDelta           `json:"delta"`
+	Complete bool                `json:"complete"`
+	Missing  []string            `json:"missing,omitempty"`
+}
+
+// localityCosts prices each locality for c and ranks them cheapest first, with deltas
+// against the top-ranked one. Each analysis's InflationRate blends rates by component.
+// Commutes to a workplace are fetched concurrently before the localities are priced.
+func localityCosts(r *http.Request, names []string, c costContext, rates inflationRates) ([]localityCost, error) {
+	groceries, err := groceryCost(r, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var commutes []*commute
+	if c.Workplace != "" {
+		if commutes, err = commutesFrom(r, names, c.Workplace); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]localityCost, 0, len(names))
+	for i, name := range names {
+		lc := localityCost{Locality: name, Complete: true}
+		var avgRent float64
+		if err := conn.QueryRow(`
+			SELECT COALESCE(AVG(rent), 0), COUNT(*)
+			FROM rental_listings
+			WHERE locality = $1
+		`, name).Scan(&avgRent, &lc.Listings); err != nil {
+			return nil, err
+		}
+		if lc.Listings == 0 {
+			lc.Complete = false
+			lc.Missing = append(lc.Missing, "rent")
+		}
+
+		var transport float64
+		switch {
+		case c.Workplace != "":
+			lc.Commute = commutes[i]
+		case c.CommuteDistance > 0:
+			lc.Commute = commuteOver(c.CommuteDistance)
+		}
+		if lc.Commute == nil {
+			lc.Complete = false
+			lc.Missing = append(lc.Missing, "commute")
+		} else {
+			transport = lc.Commute.MonthlyCost
+		}
+
+		lc.Analysis = models.CostAnalysis{
+			Rent:      round2(avgRent),
+			Groceries: round2(groceries),
+			Transport: round2(transport),
+			Total:     round2(avgRent + groceries + transport),
+		}
+		if c.Income > 0 {
+			lc.Analysis.CostBurden = round2(lc.Analysis.Total / c.Income * 100)
+		}
+		lc.Analysis.InflationRate = rates.blended(lc.Analysis)
+		out = append(out, lc)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Complete != out[j].Complete {
+			return out[i].Complete
+		}
+		return out[i].Analysis.Total < out[j].Analysis.Total
+	})
+	if len(out) > 0 {
+		best := out[0].Analysis
+		for i := range out {
+			a := out[i].Analysis
+			out[i].Rank = i + 1
+			out[i].Delta = costDelta{
+				Rent:      round2(a.Rent - best.Rent),
+				Groceries: round2(a.Groceries - best.Groceries),
+				Transport: round2(a.Transport - best.Transport),
+				Total:     round2(a.Total - best.Total),
+			}
+		}
+	}
+	return out, nil
+}
+
+// handleCompare serves GET /compare: the monthly rent, groceries and commute of 2-10
+// localities (?localities=a,b,c, or the older ?loc1=&loc2=), ranked cheapest first.
+// Groceries are the household's basket from grocery-service and the commute is the
+// cheapest mode to the workplace from transport-service; see parseCostContext.
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	var refs []string
+	if v := q.Get("localities"); v != "" {
+		for _, ref := range strings.Split(v, ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	} else if q.Get("loc1") != "" && q.Get("loc2") != "" {
+		refs = []string{q.Get("loc1"), q.Get("loc2")}
+	}
+	if len(refs) < 2 || len(refs) > maxCompare {
+		http.Error(w, fmt.Sprintf("localities must list 2-%d localities", maxCompare), http.StatusBadRequest)
+		return
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, ref := range refs {
+		l, err := localities.Resolve(ref)
+		if err != nil {
+			writeCompareError(w, err)
+			return
+		}
+		if !seen[l.Name] {
+			seen[l.Name] = true
+			names = append(names, l.Name)
+		}
+	}
+
+	c, err := parseCostContext(r)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	rates, ratesErr := ratesOrNone(r)
+	costs, err := localityCosts(r, names, c, rates)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	ranking := make([]string, len(costs))
+	for i, lc := range costs {
+		ranking[i] = lc.Locality
+	}
+	resp := map[string]interface{}{
+		"context":    c,
+		"localities": costs,
+		"ranking":    ranking,
+	}
+	if ratesErr != "" {
+		resp["inflation_error"] = ratesErr
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleCostBurden serves GET /cost-burden: every locality with listings, priced as in
+// /compare, with its total as a share of income. Income comes from ?income= or the
+// profile of ?user_id=.
+func handleCostBurden(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	c, err := parseCostContext(r)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	if c.Income <= 0 {
+		http.Error(w, "income required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := conn.Query(`SELECT DISTINCT locality FROM rental_listings WHERE locality IS NOT NULL`)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rates, ratesErr := ratesOrNone(r)
+	costs, err := localityCosts(r, names, c, rates)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+
+	type burdenRow struct {
+		Locality  string  `json:"locality"`
+		AvgRent   float64 `json:"avg_rent"`
+		Groceries float64 `json:"groceries"`
+		Transport float64 `json:"transport"`
+		Total     float64 `json:"total"`
+		Burden    float64 `json:"burden_pct"`
+		Complete  bool    `json:"complete"`
+	}
+	result := []burdenRow{}
+	for _, lc := range costs {
+		a := lc.Analysis
+		result = append(result, burdenRow{
+			Locality: lc.Locality, AvgRent: a.Rent, Groceries: a.Groceries, Transport: a.Transport,
+			Total: a.Total, Burden: a.CostBurden, Complete: lc.Complete,
+		})
+	}
+	resp := map[string]interface{}{"income": c.Income, "context": c, "localities": result}
+	if ratesErr != "" {
+		resp["inflation_error"] = ratesErr
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rent-cost-analyzer/internal/fare"
+)
+
+// fakeTransport answers /route with a bus quote whose fare is the length of from, and
+// tracks how many calls are in flight at once.
+type fakeTransport struct {
+	delay time.Duration
+	fail  string // a from locality answered with a 500
+
+	mu            sync.Mutex
+	inFlight, max int
+}
+
+func (f *fakeTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.inFlight++
+	f.max = max(f.max, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(f.delay):
+	case <-r.Context().Done():
+		return
+	}
+	from := r.URL.Query().Get("from")
+	if from == f.fail {
+		http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"found":    true,
+		"legs":     []int{1},
+		"modes":    []fare.Quote{{Mode: fare.Bus, Fare: float64(len(from))}},
+		"cheapest": fare.Bus,
+	})
+}
+
+func TestCommutesFrom(t *testing.T) {
+	names := make([]string, 10)
+	for i := range names {
+		names[i] = "L" + strings.Repeat("x", i)
+	}
+
+	t.Run("bounded and in order", func(t *testing.T) {
+		f := &fakeTransport{delay: 20 * time.Millisecond}
+		srv := httptest.NewServer(f)
+		defer srv.Close()
+		t.Setenv("TRANSPORT_SERVICE_URL", srv.URL)
+
+		got, err := commutesFrom(httptest.NewRequest(http.MethodGet, "/compare", nil), names, "Work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, c := range got {
+			if c == nil || c.Fare != float64(len(names[i])) {
+				t.Errorf("commute %d = %+v, want fare %d", i, c, len(names[i]))
+			}
+		}
+		if f.max > commuteWorkers || f.max < 2 {
+			t.Errorf("%d calls in flight at most, want 2-%d", f.max, commuteWorkers)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := httptest.NewServer(&fakeTransport{fail: names[3]})
+		defer srv.Close()
+		t.Setenv("TRANSPORT_SERVICE_URL", srv.URL)
+
+		_, err := commutesFrom(httptest.NewRequest(http.MethodGet, "/compare", nil), names, "Work")
+		se, ok := err.(*statusError)
+		if !ok || se.status != http.StatusBadGateway {
+			t.Errorf("error = %v, want a 502 statusError", err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// listingImport imports rental_listings rows: locality (a registered name or slug), rent,
+// bedrooms, sqft, lat, lon and an optional distance. Imported listings are classified once
+// the import commits.
+var listingImport = importer.Spec[models.RentalListing]{
+	Parse: func(row *importer.Row) models.RentalListing {
+		l := models.RentalListing{
+			Locality: row.String("locality", true),
+			Rent:     row.Float("rent"),
+			Bedrooms: row.Int("bedrooms"),
+			Sqft:     row.Int("sqft"),
+			Distance: row.OptionalFloat("distance", 0),
+			Lat:      row.Float("lat"),
+			Lon:      row.Float("lon"),
+		}
+		if len(row.Errors()) > 0 {
+			return l
+		}
+		errs := validateListing(&l)
+		fields := make([]string, 0, len(errs))
+		for f := range errs {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		for _, f := range fields {
+			row.Errorf(f, "%s", errs[f])
+		}
+		return l
+	},
+	Insert: func(tx *sql.Tx, listings []models.RentalListing) error {
+		stmt, err := tx.Prepare(`
+			INSERT INTO rental_listings (locality, rent, bedrooms, sqft, classification, distance, lat, lon)
+			VALUES ($1, $2, $3, $4, 'fair', $5, $6, $7)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		seen := map[int]bool{}
+		var bedrooms []int
+		for _, l := range listings {
+			if _, err := stmt.Exec(l.Locality, l.Rent, l.Bedrooms, l.Sqft, l.Distance, l.Lat, l.Lon); err != nil {
+				return err
+			}
+			if !seen[l.Bedrooms] {
+				seen[l.Bedrooms] = true
+				bedrooms = append(bedrooms, l.Bedrooms)
+			}
+		}
+		return reclassify(tx, bedrooms...)
+	},
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// validateListing checks a listing before it is written and returns a field -> message map
+// (empty when valid). The locality must be registered; it is given as a name or slug and
+// rewritten to the registered name.
+func validateListing(l *models.RentalListing) map[string]string {
+	errs := map[string]string{}
+	if strings.TrimSpace(l.Locality) == "" {
+		errs["locality"] = "required"
+	} else if loc, err := localities.Resolve(l.Locality); errors.Is(err, locality.ErrUnknown) {
+		errs["locality"] = "unknown locality; see GET /api/geospatial/localities"
+	} else if err != nil {
+		errs["locality"] = err.Error()
+	} else {
+		l.Locality = loc.Name
+	}
+	if l.Rent <= 0 {
+		errs["rent"] = "must be positive"
+	}
+	if l.Bedrooms <= 0 {
+		errs["bedrooms"] = "must be positive"
+	}
+	if l.Sqft <= 0 {
+		errs["sqft"] = "must be positive"
+	}
+	if l.Distance < 0 {
+		errs["distance"] = "must not be negative"
+	}
+	if l.Lat < locality.MinLat || l.Lat > locality.MaxLat {
+		errs["lat"] = "must be within the Ashta bounding box"
+	}
+	if l.Lon < locality.MinLon || l.Lon > locality.MaxLon {
+		errs["lon"] = "must be within the Ashta bounding box"
+	}
+	return errs
+}
+
+func writeValidationError(w http.ResponseWriter, errs map[string]string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "validation failed", "fields": errs})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": "listing not found"})
+}
+
+func getListing(c *sql.DB, id int) (models.RentalListing, error) {
+	var l models.RentalListing
+	err := c.QueryRow(`
+		SELECT id, locality, rent, bedrooms, sqft, classification,
+			COALESCE(expected_rent, 0), COALESCE(z_score, 0), distance, COALESCE(lat, 0), COALESCE(lon, 0)
+		FROM rental_listings WHERE id = $1
+	`, id).Scan(&l.ID, &l.Locality, &l.Rent, &l.Bedrooms, &l.Sqft, &l.Classification,
+		&l.ExpectedRent, &l.ZScore, &l.Distance, &l.Lat, &l.Lon)
+	return l, err
+}
+
+// writeListing runs write inside a transaction, reclassifies the listings sharing any of
+// the bedroom counts it touched in the same transaction, and commits. Either both persist
+// or neither does.
+func writeListing(write func(tx *sql.Tx) error, bedrooms ...int) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := write(tx); err != nil {
+		return err
+	}
+	if err := reclassify(tx, bedrooms...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeStored responds with the listing as stored, classification included.
+func writeStored(w http.ResponseWriter, status, id int) {
+	l, err := getListing(conn, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(l)
+}
+
+// handleCreateListing serves POST /listings.
+func handleCreateListing(w http.ResponseWriter, r *http.Request) {
+	var l models.RentalListing
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errs := validateListing(&l); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	err := writeListing(func(tx *sql.Tx) error {
+		return tx.QueryRow(`
+			INSERT INTO rental_listings (locality, rent, bedrooms, sqft, classification, distance, lat, lon)
+			VALUES ($1, $2, $3, $4, 'fair', $5, $6, $7)
+			RETURNING id
+		`, l.Locality, l.Rent, l.Bedrooms, l.Sqft, l.Distance, l.Lat, l.Lon).Scan(&l.ID)
+	}, l.Bedrooms)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeStored(w, http.StatusCreated, l.ID)
+}
+
+// handleListingByID serves GET, PUT, PATCH and DELETE on /listings/{id}, and
+// GET /listings/{id}/valuation.
+func handleListingByID(w http.ResponseWriter, r *http.Request) {
+	idPart, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/listings/"), "/")
+	if sub == "valuation" {
+		handleValuation(w, r, idPart)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 || sub != "" {
+		writeNotFound(w)
+		return
+	}
+
+	existing, err := getListing(conn, id)
+	if err == sql.ErrNoRows {
+		writeNotFound(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(existing)
+		return
+
+	case http.MethodPut, http.MethodPatch:
+		// PUT replaces the listing; PATCH decodes onto the stored one so omitted fields keep their values.
+		l := models.RentalListing{}
+		if r.Method == http.MethodPatch {
+			l = existing
+		}
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.ID = id
+		if errs := validateListing(&l); len(errs) > 0 {
+			writeValidationError(w, errs)
+			return
+		}
+
+		err := writeListing(func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+				UPDATE rental_listings
+				SET locality = $1, rent = $2, bedrooms = $3, sqft = $4, distance = $5, lat = $6, lon = $7
+				WHERE id = $8
+			`, l.Locality, l.Rent, l.Bedrooms, l.Sqft, l.Distance, l.Lat, l.Lon, id)
+			return err
+		}, existing.Bedrooms, l.Bedrooms)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeStored(w, http.StatusOK, id)
+		return
+
+	case http.MethodDelete:
+		err := writeListing(func(tx *sql.Tx) error {
+			_, err := tx.Exec("DELETE FROM rental_listings WHERE id = $1", id)
+			return err
+		}, existing.Bedrooms)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleValuation serves GET /listings/{id}/valuation: the expected rent, z-score and the
+// comparables behind the listing's classification.
+func handleValuation(w http.ResponseWriter, r *http.Request, idPart string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		writeNotFound(w)
+		return
+	}
+
+	l, err := getListing(conn, id)
+	if err == sql.ErrNoRows {
+		writeNotFound(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	group, err := loadListings(conn, l.Bedrooms)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	v := valueListing(l, group)
+	comps := v.Comparables
+	if comps == nil {
+		comps = []models.RentalListing{}
+	}
+	json.NewEncoder(w).Encode(models.ListingValuation{
+		ListingID:      l.ID,
+		Rent:           l.Rent,
+		ExpectedRent:   v.ExpectedRent,
+		ZScore:         v.ZScore,
+		Classification: v.Classification,
+		Basis:          v.Basis,
+		MeanPerSqft:    v.MeanPerSqft,
+		StdDevPerSqft:  v.StdDevPerSqft,
+		Comparables:    comps,
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultListingsLimit = 10
+	maxListingsLimit     = 100
+)
+
+// listingSortColumns maps the public sort field names to their columns.
+var listingSortColumns = map[string]string{
+	"rent":     "rent",
+	"bedrooms": "bedrooms",
+	"sqft":     "sqft",
+	"distance": "distance",
+	"id":       "id",
+}
+
+// listingCursor marks the last row of a page: the sort field and direction it was issued
+// for, the sort value and the id used as tie-breaker.
+type listingCursor struct {
+	Sort  string  `json:"s"`
+	Desc  bool    `json:"d,omitempty"`
+	Value float64 `json:"v"`
+	ID    int     `json:"id"`
+}
+
+func encodeCursor(c listingCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (listingCursor, error) {
+	var c listingCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// listingQuery is a parsed GET /listings request.
+type listingQuery struct {
+	where  []string
+	args   []interface{}
+	sort   string
+	desc   bool
+	limit  int
+	cursor *listingCursor
+}
+
+// parseListingQuery turns the query string into SQL filter clauses and paging options.
+// Range filters use min_/max_ prefixes (min_rent, max_rent, min_bedrooms, ...).
+func parseListingQuery(q url.Values) (*listingQuery, error) {
+	lq := &listingQuery{sort: "rent", limit: defaultListingsLimit}
+
+	if v := q.Get("locality"); v != "" {
+		lq.add("LOWER(locality) = LOWER($%d)", v)
+	}
+	if v := q.Get("classification"); v != "" {
+		lq.add("classification = $%d", strings.ToLower(v))
+	}
+
+	ranges := []struct {
+		param, clause string
+		isInt         bool
+	}{
+		{"min_bedrooms", "bedrooms >= $%d", true},
+		{"max_bedrooms", "bedrooms <= $%d", true},
+		{"min_rent", "rent >= $%d", false},
+		{"max_rent", "rent <= $%d", false},
+		{"min_sqft", "sqft >= $%d", true},
+		{"max_sqft", "sqft <= $%d", true},
+		{"max_distance", "distance <= $%d", false},
+	}
+	for _, rg := range ranges {
+		v := q.Get(rg.param)
+		if v == "" {
+			continue
+		}
+		if rg.isInt {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s", rg.param)
+			}
+			lq.add(rg.clause, n)
+		} else {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				return nil, fmt.Errorf("invalid %s", rg.param)
+			}
+			lq.add(rg.clause, f)
+		}
+	}
+
+	if v := q.Get("sort"); v != "" {
+		if _, ok := listingSortColumns[v]; !ok {
+			return nil, fmt.Errorf("invalid sort: must be one of rent, bedrooms, sqft, distance, id")
+		}
+		lq.sort = v
+	}
+	switch strings.ToLower(q.Get("order")) {
+	case "", "asc":
+	case "desc":
+		lq.desc = true
+	default:
+		return nil, fmt.Errorf("invalid order: must be asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListingsLimit {
+			return nil, fmt.Errorf("invalid limit: must be 1-%d", maxListingsLimit)
+		}
+		lq.limit = n
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != lq.sort {
+			return nil, fmt.Errorf("cursor was issued for sort=%s", c.Sort)
+		}
+		if c.Desc != lq.desc {
+			return nil, fmt.Errorf("cursor was issued for order=%s", orderName(c.Desc))
+		}
+		lq.cursor = &c
+	}
+
+	return lq, nil
+}
+
+func orderName(desc bool) string {
+	if desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// add appends a filter clause; the clause's %d verb is replaced with the arg's placeholder index.
+func (lq *listingQuery) add(clause string, arg interface{}) {
+	lq.args = append(lq.args, arg)
+	lq.where = append(lq.where, fmt.Sprintf(clause, len(lq.args)))
+}
+
+func (lq *listingQuery) whereSQL(clauses []string) string {
+	if len(clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(clauses, " AND ")
+}
+
+// countSQL returns the total-count query for the filters, ignoring the cursor.
+func (lq *listingQuery) countSQL() (string, []interface{}) {
+	return "SELECT COUNT(*) FROM rental_listings" + lq.whereSQL(lq.where), lq.args
+}
+
+// pageSQL returns the page query. It fetches limit+1 rows so the caller can tell whether
+// another page follows.
+func (lq *listingQuery) pageSQL() (string, []interface{}) {
+	col := listingSortColumns[lq.sort]
+	dir, cmp := "ASC", ">"
+	if lq.desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	where := append([]string{}, lq.where...)
+	args := append([]interface{}{}, lq.args...)
+	if lq.cursor != nil {
+		args = append(args, lq.cursor.Value, lq.cursor.ID)
+		where = append(where, fmt.Sprintf("(%s, id) %s ($%d, $%d)", col, cmp, len(args)-1, len(args)))
+	}
+	args = append(args, lq.limit+1)
+
+	query := fmt.Sprintf(`
+		SELECT id, locality, rent, bedrooms, sqft, classification,
+			COALESCE(expected_rent, 0), COALESCE(z_score, 0), distance
+		FROM rental_listings%s
+		ORDER BY %s %s, id %s
+		LIMIT $%d`, lq.whereSQL(where), col, dir, dir, len(args))
+	return query, args
+}
+
+// cursorFor builds the cursor pointing just past l for the query's sort field and order.
+func (lq *listingQuery) cursorFor(l models.RentalListing) string {
+	var v float64
+	switch lq.sort {
+	case "rent":
+		v = l.Rent
+	case "bedrooms":
+		v = float64(l.Bedrooms)
+	case "sqft":
+		v = float64(l.Sqft)
+	case "distance":
+		v = l.Distance
+	case "id":
+		v = float64(l.ID)
+	}
+	return encodeCursor(listingCursor{Sort: lq.sort, Desc: lq.desc, Value: v, ID: l.ID})
+}
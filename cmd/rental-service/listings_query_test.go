@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+func TestListingCursor(t *testing.T) {
+	issue := func(query string) string {
+		lq, err := parseListingQuery(mustQuery(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lq.cursorFor(models.RentalListing{ID: 7, Rent: 18000, Sqft: 650})
+	}
+	rentAsc, rentDesc, sqftAsc := issue("sort=rent"), issue("sort=rent&order=desc"), issue("sort=sqft")
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"same sort and order", "sort=rent&cursor=" + rentAsc, ""},
+		{"explicit asc", "sort=rent&order=asc&cursor=" + rentAsc, ""},
+		{"desc", "sort=rent&order=desc&cursor=" + rentDesc, ""},
+		{"asc cursor on desc query", "sort=rent&order=desc&cursor=" + rentAsc, "order=asc"},
+		{"desc cursor on asc query", "sort=rent&cursor=" + rentDesc, "order=desc"},
+		{"other sort", "sort=rent&cursor=" + sqftAsc, "sort=sqft"},
+		{"garbage", "cursor=not-a-cursor", "invalid cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lq, err := parseListingQuery(mustQuery(tt.query))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parseListingQuery() error = %v", err)
+				}
+				if lq.cursor == nil || lq.cursor.ID != 7 || lq.cursor.Value != 18000 {
+					t.Errorf("cursor = %+v, want rent 18000 and id 7", lq.cursor)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseListingQuery() error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPageSQLDirection(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"sort=rent", []string{"(rent, id) > ($1, $2)", "ORDER BY rent ASC, id ASC"}},
+		{"sort=rent&order=desc", []string{"(rent, id) < ($1, $2)", "ORDER BY rent DESC, id DESC"}},
+	}
+	for _, tt := range tests {
+		lq, err := parseListingQuery(mustQuery(tt.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lq, err = parseListingQuery(mustQuery(tt.query + "&cursor=" + lq.cursorFor(models.RentalListing{ID: 1, Rent: 100})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sql, _ := lq.pageSQL()
+		for _, w := range tt.want {
+			if !strings.Contains(sql, w) {
+				t.Errorf("%s: page query lacks %q:\n%s", tt.query, w, sql)
+			}
+		}
+	}
+}
+
+func mustQuery(s string) url.Values {
+	q, err := url.ParseQuery(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
@@ -3,238 +3,161 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"log"
-	"math/rand"
 	"net/http"
-	"strconv"
 
+	"rent-cost-analyzer/internal/auth"
 	"rent-cost-analyzer/internal/db"
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/internal/seed"
 	"rent-cost-analyzer/pkg/models"
 )
 
-var conn *sql.DB
+var (
+	conn       *sql.DB
+	localities *locality.Registry
+)
 
 func main() {
+	seedConfig := seed.FromEnv()
+	seedConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
 	c, err := db.Open()
 	if err != nil {
 		log.Fatal("db open:", err)
 	}
 	defer c.Close()
 	conn = c
+	localities = locality.NewRegistry(conn)
 
-	initTables(conn)
-	seedMockData(conn)
+	if n, err := db.MigrateUp(conn, "rental"); err != nil {
+		log.Fatal("migrate:", err)
+	} else if n > 0 {
+		log.Printf("applied %d rental migration(s)", n)
+	}
+	if _, err := seed.IfEmpty(conn, mockData, seedConfig); err != nil {
+		log.Println("seed listings:", err)
+	}
+	if err := reclassifyAll(conn); err != nil {
+		log.Println("reclassify listings:", err)
+	}
 
-	http.HandleFunc("/listings", handleListings)
-	http.HandleFunc("/listings/summary", handleListingsSummary)
-	http.HandleFunc("/compare", handleCompare)
-	http.HandleFunc("/cost-burden", handleCostBurden)
+	http.HandleFunc("/listings", auth.ByMethod(handleListings))
+	http.HandleFunc("/listings/", auth.ByMethod(handleListingByID))
+	http.HandleFunc("/listings/summary", auth.Require(auth.ScopeRead, handleListingsSummary))
+	http.HandleFunc("/compare", auth.Require(auth.ScopeRead, handleCompare))
+	http.HandleFunc("/cost-burden", auth.Require(auth.ScopeRead, handleCostBurden))
+	http.HandleFunc("/project", auth.Require(auth.ScopeRead, handleProject))
+	http.HandleFunc("/import", auth.Require(auth.ScopeWrite, importer.Handler(conn, listingImport)))
+	http.HandleFunc("/admin/reset-and-seed", auth.Require(auth.ScopeAdmin, seed.Handler(conn, seedConfig, mockData)))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("rental-service listening on :8082")
 	log.Fatal(http.ListenAndServe(":8082", nil))
 }
 
-func initTables(c *sql.DB) {
-	_, err := c.Exec(`
-		CREATE TABLE IF NOT EXISTS rental_listings (
-			id SERIAL PRIMARY KEY,
-			locality VARCHAR(100),
-			rent DECIMAL(10,2),
-			bedrooms INT,
-			sqft INT,
-			classification VARCHAR(20),
-			distance DECIMAL(10,2),
-			lat DECIMAL(10,6),
-			lon DECIMAL(10,6)
-		);
-	`)
-	if err != nil {
-		log.Fatal("create table:", err)
-	}
+// mockData regenerates the listings from the seed config; classifications are then
+// recomputed by reclassifyAll.
+var mockData = seed.Reset{
+	Tables: []string{"rental_listings"},
+	Fill: func(tx *sql.Tx, c seed.Config) (int, error) {
+		locs, err := seed.Localities(tx, c)
+		if err != nil {
+			return 0, err
+		}
+		listings := seed.Listings(c, locs)
+		for _, l := range listings {
+			if _, err := tx.Exec(`INSERT INTO rental_listings (locality, rent, bedrooms, sqft, classification, distance, lat, lon)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				l.Locality, l.Rent, l.Bedrooms, l.Sqft, l.Classification, l.Distance, l.Lat, l.Lon); err != nil {
+				return 0, err
+			}
+		}
+		return len(listings), nil
+	},
+	AfterCommit: func(seed.Config) error { return reclassifyAll(conn) },
 }
 
-func seedMockData(c *sql.DB) {
-	var count int
-	c.QueryRow("SELECT COUNT(*) FROM rental_listings").Scan(&count)
-	if count > 0 {
+func handleListings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		w.Header().Set("Content-Type", "application/json")
+		handleCreateListing(w, r)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 
-	localities := []string{"Ashta Central", "Railway Colony", "Industrial Area", "Market Ward", "Gandhi Nagar", "Nehru Colony"}
-
-	for i := 0; i < 20; i++ {
-		locality := localities[rand.Intn(len(localities))]
-		bedrooms := rand.Intn(3) + 1
-		sqft := 400 + rand.Intn(1200)
-		baseRent := float64(bedrooms)*2500 + float64(sqft)*0.5
-		rent := baseRent + rand.Float64()*1000 - 500
-
-		classification := "fair"
-		if rand.Float64() > 0.7 {
-			classification = "overpriced"
-			rent *= 1.3
-		}
-
-		distance := rand.Float64() * 10
-		lat := 23.0198 + (rand.Float64()-0.5)*0.1
-		lon := 76.7224 + (rand.Float64()-0.5)*0.1
-
-		c.Exec(`INSERT INTO rental_listings (locality, rent, bedrooms, sqft, classification, distance, lat, lon) 
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-			locality, rent, bedrooms, sqft, classification, distance, lat, lon)
+	lq, err := parseListingQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-}
 
-func handleListings(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	var total int
+	countQuery, countArgs := lq.countSQL()
+	if err := conn.QueryRow(countQuery, countArgs...).Scan(&total); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := conn.Query(`
-		SELECT id, locality, rent, bedrooms, sqft, classification, distance 
-		FROM rental_listings 
-		ORDER BY rent 
-		LIMIT 10
-	`)
+	pageQuery, pageArgs := lq.pageSQL()
+	rows, err := conn.Query(pageQuery, pageArgs...)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
-	var list []models.RentalListing
+	list := []models.RentalListing{}
 	for rows.Next() {
 		var l models.RentalListing
-		err := rows.Scan(&l.ID, &l.Locality, &l.Rent, &l.Bedrooms, &l.Sqft, &l.Classification, &l.Distance)
+		err := rows.Scan(&l.ID, &l.Locality, &l.Rent, &l.Bedrooms, &l.Sqft, &l.Classification,
+			&l.ExpectedRent, &l.ZScore, &l.Distance)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		list = append(list, l)
 	}
-	json.NewEncoder(w).Encode(map[string]interface{}{"listings": list})
-}
 
-func handleListingsSummary(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+	nextCursor := ""
+	if len(list) > lq.limit {
+		list = list[:lq.limit]
+		nextCursor = lq.cursorFor(list[len(list)-1])
 	}
-	w.Header().Set("Content-Type", "application/json")
-
-	var fair, overpriced int
-	conn.QueryRow("SELECT COUNT(*) FROM rental_listings WHERE classification = 'fair'").Scan(&fair)
-	conn.QueryRow("SELECT COUNT(*) FROM rental_listings WHERE classification = 'overpriced'").Scan(&overpriced)
-
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"fair":       fair,
-		"overpriced": overpriced,
+		"listings":    list,
+		"total":       total,
+		"limit":       lq.limit,
+		"next_cursor": nextCursor,
 	})
 }
 
-func handleCompare(w http.ResponseWriter, r *http.Request) {
+func handleListingsSummary(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 
-	loc1 := r.URL.Query().Get("loc1")
-	loc2 := r.URL.Query().Get("loc2")
-	if loc1 == "" || loc2 == "" {
-		http.Error(w, "loc1 and loc2 required", http.StatusBadRequest)
-		return
-	}
-
-	a1 := getLocalityAnalysis(conn, loc1)
-	a2 := getLocalityAnalysis(conn, loc2)
+	var fair, overpriced, underpriced int
+	conn.QueryRow("SELECT COUNT(*) FROM rental_listings WHERE classification = 'fair'").Scan(&fair)
+	conn.QueryRow("SELECT COUNT(*) FROM rental_listings WHERE classification = 'overpriced'").Scan(&overpriced)
+	conn.QueryRow("SELECT COUNT(*) FROM rental_listings WHERE classification = 'underpriced'").Scan(&underpriced)
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"locality1": loc1,
-		"locality2": loc2,
-		"analysis1": a1,
-		"analysis2": a2,
+		"fair":        fair,
+		"overpriced":  overpriced,
+		"underpriced": underpriced,
 	})
 }
-
-func getLocalityAnalysis(c *sql.DB, locality string) models.CostAnalysis {
-	var avgRent float64
-	c.QueryRow(`
-		SELECT COALESCE(AVG(rent), 5000) 
-		FROM rental_listings 
-		WHERE locality LIKE $1
-	`, "%"+locality+"%").Scan(&avgRent)
-
-	groceries := 3000.0 + rand.Float64()*500
-	transport := 1500.0 + rand.Float64()*500
-	total := avgRent + groceries + transport
-
-	return models.CostAnalysis{
-		Rent:      avgRent,
-		Groceries: groceries,
-		Transport: transport,
-		Total:     total,
-	}
-}
-
-func handleCostBurden(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-
-	incomeStr := r.URL.Query().Get("income")
-	if incomeStr == "" {
-		http.Error(w, "income required", http.StatusBadRequest)
-		return
-	}
-	income, err := strconv.ParseFloat(incomeStr, 64)
-	if err != nil || income <= 0 {
-		http.Error(w, "invalid income", http.StatusBadRequest)
-		return
-	}
-
-	rows, err := conn.Query(`
-		SELECT locality, AVG(rent) as avg_rent
-		FROM rental_listings
-		GROUP BY locality
-		ORDER BY avg_rent
-	`)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	type burdenRow struct {
-		Locality string  `json:"locality"`
-		AvgRent  float64 `json:"avg_rent"`
-		Total    float64 `json:"total"`
-		Burden   float64 `json:"burden_pct"`
-	}
-	var result []burdenRow
-
-	for rows.Next() {
-		var locality string
-		var rent float64
-		rows.Scan(&locality, &rent)
-
-		groceries := 3000.0
-		transport := 1500.0
-		total := rent + groceries + transport
-		burden := (total / income) * 100
-
-		result = append(result, burdenRow{
-			Locality: locality,
-			AvgRent:  rent,
-			Total:    total,
-			Burden:   burden,
-		})
-	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{"income": income, "localities": result})
-}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultHorizonMonths = 12
+	maxHorizonMonths     = 120
+	defaultThresholdPct  = 50.0
+)
+
+// componentRate is the annual inflation rate a cost component grows at, and the
+// inflation_data category and month it came from.
+type componentRate struct {
+	Category   string  `json:"category"`
+	Month      string  `json:"month,omitempty"`
+	AnnualRate float64 `json:"annual_rate"`
+}
+
+// inflationRates are the rates for rent (Housing), groceries (Food) and transport
+// (Transport). A category with no data falls back to Overall, and to 0 without that.
+type inflationRates struct {
+	Rent      componentRate `json:"rent"`
+	Groceries componentRate `json:"groceries"`
+	Transport componentRate `json:"transport"`
+}
+
+// fetchRates reads each category's latest rate from inflation-service.
+func fetchRates(r *http.Request) (inflationRates, error) {
+	var latest struct {
+		Rates map[string]struct {
+			Month string  `json:"month"`
+			Rate  float64 `json:"rate"`
+		} `json:"rates"`
+	}
+	u := serviceURL("INFLATION_SERVICE_URL", defaultInflationServiceURL) + "/latest"
+	if err := getJSON(r, "inflation-service", u, &latest); err != nil {
+		return inflationRates{}, err
+	}
+	pick := func(category string) componentRate {
+		for _, cat := range []string{category, "Overall"} {
+			if v, ok := latest.Rates[cat]; ok {
+				return componentRate{Category: cat, Month: v.Month, AnnualRate: v.Rate}
+			}
+		}
+		return componentRate{Category: "none"}
+	}
+	return inflationRates{Rent: pick("Housing"), Groceries: pick("Food"), Transport: pick("Transport")}, nil
+}
+
+// ratesOrNone is fetchRates for /compare and /cost-burden, where inflation is only a
+// detail of each analysis: if inflation-service fails it logs why and returns rates of 0,
+// with the reason to report, instead of failing the request.
+func ratesOrNone(r *http.Request) (inflationRates, string) {
+	rates, err := fetchRates(r)
+	if err != nil {
+		log.Println("inflation rates unavailable:", err)
+		none := componentRate{Category: "none"}
+		return inflationRates{Rent: none, Groceries: none, Transport: none}, err.Error()
+	}
+	return rates, ""
+}
+
+// blended is the annual rate of a month's total cost: the component rates weighted by
+// each component's share of it.
+func (ir inflationRates) blended(a models.CostAnalysis) float64 {
+	if a.Total <= 0 {
+		return 0
+	}
+	return round2((a.Rent*ir.Rent.AnnualRate + a.Groceries*ir.Groceries.AnnualRate + a.Transport*ir.Transport.AnnualRate) / a.Total)
+}
+
+// projectionPoint is the monthly cost Month months from now.
+type projectionPoint struct {
+	Month      int     `json:"month"`
+	Date       string  `json:"date"`
+	Rent       float64 `json:"rent"`
+	Groceries  float64 `json:"groceries"`
+	Transport  float64 `json:"transport"`
+	Total      float64 `json:"total"`
+	Income     float64 `json:"income,omitempty"`
+	CostBurden float64 `json:"cost_burden,omitempty"`
+}
+
+// grow compounds an annual percentage rate over months.
+func grow(v, annualPct float64, months int) float64 {
+	return v * math.Pow(1+annualPct/100, float64(months)/12)
+}
+
+// project compounds each component at its rate, and income at incomeGrowth, for months
+// 0 (now) to horizon. Burden is only set when income is known.
+func project(base models.CostAnalysis, rates inflationRates, income, incomeGrowth float64, horizon int, start time.Time) []projectionPoint {
+	out := make([]projectionPoint, 0, horizon+1)
+	for m := 0; m <= horizon; m++ {
+		p := projectionPoint{
+			Month:     m,
+			Date:      start.AddDate(0, m, 0).Format("2006-01"),
+			Rent:      grow(base.Rent, rates.Rent.AnnualRate, m),
+			Groceries: grow(base.Groceries, rates.Groceries.AnnualRate, m),
+			Transport: grow(base.Transport, rates.Transport.AnnualRate, m),
+		}
+		p.Total = p.Rent + p.Groceries + p.Transport
+		if income > 0 {
+			p.Income = grow(income, incomeGrowth, m)
+			p.CostBurden = round2(p.Total / p.Income * 100)
+			p.Income = round2(p.Income)
+		}
+		p.Rent, p.Groceries, p.Transport, p.Total = round2(p.Rent), round2(p.Groceries), round2(p.Transport), round2(p.Total)
+		out = append(out, p)
+	}
+	return out
+}
+
+// crossover is the first point whose burden exceeds thresholdPct, or nil if none does.
+func crossover(series []projectionPoint, thresholdPct float64) *projectionPoint {
+	for i := range series {
+		if series[i].Income > 0 && series[i].CostBurden > thresholdPct {
+			return &series[i]
+		}
+	}
+	return nil
+}
+
+// handleProject serves GET /project: a locality's monthly cost (priced as in /compare)
+// projected ?horizon= months ahead (default 12, at most 120) with rent, groceries and
+// transport each growing at their own category's latest inflation rate. The locality is
+// ?locality= or the profile's preferred one. With an income, each month carries the cost
+// burden and crossover is the first month it exceeds ?threshold= percent (default 50);
+// ?income_growth= grows income at an annual percentage (default 0).
+func handleProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	horizon := defaultHorizonMonths
+	if v := q.Get("horizon"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxHorizonMonths {
+			http.Error(w, fmt.Sprintf("horizon must be 1-%d months", maxHorizonMonths), http.StatusBadRequest)
+			return
+		}
+		horizon = n
+	}
+	threshold := defaultThresholdPct
+	if v := q.Get("threshold"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 100 {
+			http.Error(w, "threshold must be a percentage in (0, 100]", http.StatusBadRequest)
+			return
+		}
+		threshold = f
+	}
+	incomeGrowth := 0.0
+	if v := q.Get("income_growth"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < -50 || f > 100 {
+			http.Error(w, "income_growth must be an annual percentage between -50 and 100", http.StatusBadRequest)
+			return
+		}
+		incomeGrowth = f
+	}
+
+	c, err := parseCostContext(r)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	ref := q.Get("locality")
+	if ref == "" {
+		ref = c.home
+	}
+	if ref == "" {
+		http.Error(w, "locality or user_id required", http.StatusBadRequest)
+		return
+	}
+	loc, err := localities.Resolve(ref)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+
+	rates, err := fetchRates(r)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	costs, err := localityCosts(r, []string{loc.Name}, c, rates)
+	if err != nil {
+		writeCompareError(w, err)
+		return
+	}
+	base := costs[0]
+
+	now := time.Now()
+	series := project(base.Analysis, rates, c.Income, incomeGrowth, horizon, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+	end := series[len(series)-1]
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locality":          loc.Name,
+		"context":           c,
+		"horizon_months":    horizon,
+		"threshold_pct":     threshold,
+		"income_growth_pct": incomeGrowth,
+		"rates":             rates,
+		"base":              base,
+		"series":            series,
+		"crossover":         crossover(series, threshold),
+		"increase": map[string]float64{
+			"total": round2(end.Total - series[0].Total),
+			"pct":   round2(pctChange(series[0].Total, end.Total)),
+		},
+	})
+}
+
+func pctChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	// minComparables is how many comparables a tier needs before it is trusted.
+	minComparables = 3
+	// sqftBand is the relative sqft window (±25%) for a comparable.
+	sqftBand = 0.25
+	// zThreshold is the |z| above which a listing is flagged over/underpriced.
+	zThreshold = 1.5
+)
+
+// valuation is the result of pricing one listing against its comparables.
+type valuation struct {
+	ExpectedRent   float64
+	ZScore         float64
+	Classification string
+	Basis          string
+	MeanPerSqft    float64
+	StdDevPerSqft  float64
+	Comparables    []models.RentalListing
+}
+
+// comparableTiers are tried in order until one yields minComparables matches.
+var comparableTiers = []struct {
+	basis string
+	match func(l, o models.RentalListing) bool
+}{
+	{"same locality, bedrooms and sqft band", func(l, o models.RentalListing) bool {
+		return sameLocality(l, o) && l.Bedrooms == o.Bedrooms && inSqftBand(l, o)
+	}},
+	{"same locality and bedrooms", func(l, o models.RentalListing) bool {
+		return sameLocality(l, o) && l.Bedrooms == o.Bedrooms
+	}},
+	{"same bedrooms and sqft band, any locality", func(l, o models.RentalListing) bool {
+		return l.Bedrooms == o.Bedrooms && inSqftBand(l, o)
+	}},
+}
+
+func sameLocality(l, o models.RentalListing) bool {
+	return strings.EqualFold(l.Locality, o.Locality)
+}
+
+func inSqftBand(l, o models.RentalListing) bool {
+	return math.Abs(float64(o.Sqft-l.Sqft)) <= float64(l.Sqft)*sqftBand
+}
+
+// valueListing prices l from the other listings in all. Rent is compared per sqft so that
+// size differences inside the band don't dominate the z-score.
+func valueListing(l models.RentalListing, all []models.RentalListing) valuation {
+	for _, tier := range comparableTiers {
+		var comps []models.RentalListing
+		for _, o := range all {
+			if o.ID != l.ID && o.Sqft > 0 && tier.match(l, o) {
+				comps = append(comps, o)
+			}
+		}
+		if len(comps) < minComparables {
+			continue
+		}
+
+		var sum float64
+		for _, o := range comps {
+			sum += o.Rent / float64(o.Sqft)
+		}
+		mean := sum / float64(len(comps))
+		var sq float64
+		for _, o := range comps {
+			d := o.Rent/float64(o.Sqft) - mean
+			sq += d * d
+		}
+		std := math.Sqrt(sq / float64(len(comps)-1))
+
+		v := valuation{
+			ExpectedRent:   mean * float64(l.Sqft),
+			Classification: "fair",
+			Basis:          tier.basis,
+			MeanPerSqft:    mean,
+			StdDevPerSqft:  std,
+			Comparables:    comps,
+		}
+		if std > 0 {
+			v.ZScore = (l.Rent/float64(l.Sqft) - mean) / std
+		}
+		if v.ZScore >= zThreshold {
+			v.Classification = "overpriced"
+		} else if v.ZScore <= -zThreshold {
+			v.Classification = "underpriced"
+		}
+		return v
+	}
+
+	// Too few comparables anywhere: leave the listing as fair at its own price.
+	return valuation{
+		ExpectedRent:   l.Rent,
+		Classification: "fair",
+		Basis:          "insufficient comparables",
+	}
+}
+
+// loadListings reads every listing, or only those with one of the given bedroom counts.
+func loadListings(q locality.Querier, bedrooms ...int) ([]models.RentalListing, error) {
+	query := `
+		SELECT id, locality, rent, bedrooms, sqft, COALESCE(classification, ''),
+			COALESCE(expected_rent, 0), COALESCE(z_score, 0), distance, COALESCE(lat, 0), COALESCE(lon, 0)
+		FROM rental_listings`
+	var args []interface{}
+	if len(bedrooms) > 0 {
+		query += ` WHERE bedrooms = ANY($1)`
+		args = append(args, pq.Array(bedrooms))
+	}
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RentalListing
+	for rows.Next() {
+		var l models.RentalListing
+		err := rows.Scan(&l.ID, &l.Locality, &l.Rent, &l.Bedrooms, &l.Sqft, &l.Classification,
+			&l.ExpectedRent, &l.ZScore, &l.Distance, &l.Lat, &l.Lon)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// reclassify recomputes expected rent, z-score and classification inside tx for every
+// listing with one of the given bedroom counts. Every comparables tier matches on bedrooms,
+// so a write can only shift the valuations of listings sharing the old or new bedroom count
+// of the listings it touched; only rows whose result changed are written.
+func reclassify(tx *sql.Tx, bedrooms ...int) error {
+	if len(bedrooms) == 0 {
+		return nil
+	}
+	group, err := loadListings(tx, bedrooms...)
+	if err != nil {
+		return err
+	}
+	return revalue(tx, group)
+}
+
+// reclassifyAll re-values the whole table in one transaction, after a reset or on startup.
+func reclassifyAll(c *sql.DB) error {
+	tx, err := c.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	all, err := loadListings(tx)
+	if err != nil {
+		return err
+	}
+	if err := revalue(tx, all); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revalue prices each listing in group against the rest of it and writes the changes.
+func revalue(tx *sql.Tx, group []models.RentalListing) error {
+	for _, l := range group {
+		v := valueListing(l, group)
+		expected := math.Round(v.ExpectedRent*100) / 100
+		z := math.Round(v.ZScore*100) / 100
+		if v.Classification == l.Classification && expected == l.ExpectedRent && z == l.ZScore {
+			continue
+		}
+		_, err := tx.Exec(`
+			UPDATE rental_listings SET classification = $1, expected_rent = $2, z_score = $3 WHERE id = $4
+		`, v.Classification, expected, z, l.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
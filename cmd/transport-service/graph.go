@@ -0,0 +1,189 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// Route costs /route can minimise.
+const (
+	byDistance = "distance"
+	byFare     = "fare"
+	byTime     = "time"
+)
+
+// routeGraph is transport_routes as a directed graph: each locality's outgoing routes.
+type routeGraph map[string][]models.TransportRoute
+
+// loadGraph reads every route into a graph.
+func loadGraph() (routeGraph, error) {
+	rows, err := conn.Query(`SELECT id, from_locality, to_locality, distance, fare FROM transport_routes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	g := routeGraph{}
+	for rows.Next() {
+		var rt models.TransportRoute
+		if err := rows.Scan(&rt.ID, &rt.FromLocality, &rt.ToLocality, &rt.Distance, &rt.Fare); err != nil {
+			return nil, err
+		}
+		g[rt.FromLocality] = append(g[rt.FromLocality], rt)
+	}
+	return g, rows.Err()
+}
+
+// costFunc returns the weight of a route for the given criterion, or an error for an
+// unknown one. Time is by bus during period, so each transfer costs a wait.
+func costFunc(by string, period fare.Period) (func(models.TransportRoute) float64, error) {
+	switch by {
+	case byDistance:
+		return func(rt models.TransportRoute) float64 { return rt.Distance }, nil
+	case byFare:
+		return func(rt models.TransportRoute) float64 { return rt.Fare }, nil
+	case byTime:
+		return func(rt models.TransportRoute) float64 { return fare.LegMinutes(fare.Bus, period, rt.Distance) }, nil
+	}
+	return nil, fmt.Errorf("by must be %s, %s or %s", byDistance, byFare, byTime)
+}
+
+// shortestPath returns the legs of the cheapest journey from one locality to another
+// under cost, or false if to is unreachable.
+func (g routeGraph) shortestPath(from, to string, cost func(models.TransportRoute) float64) ([]models.TransportRoute, bool) {
+	return g.dijkstra(from, to, cost).legsTo(to)
+}
+
+// paths are the cheapest journeys from one locality found by dijkstra.
+type paths struct {
+	from string
+	cost map[string]float64
+	hops map[string]int
+	via  map[string]models.TransportRoute
+	done map[string]bool
+}
+
+// dijkstra settles the cheapest journey under cost from one locality to every locality it
+// reaches, stopping early once to is settled if it is not empty. Ties go to fewer legs.
+func (g routeGraph) dijkstra(from, to string, cost func(models.TransportRoute) float64) paths {
+	p := paths{
+		from: from,
+		cost: map[string]float64{from: 0},
+		hops: map[string]int{from: 0},
+		via:  map[string]models.TransportRoute{},
+		done: map[string]bool{},
+	}
+	pq := &pathQueue{{locality: from}}
+
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(pathItem)
+		if p.done[cur.locality] {
+			continue
+		}
+		p.done[cur.locality] = true
+		if cur.locality == to {
+			break
+		}
+		for _, rt := range g[cur.locality] {
+			d, h := p.cost[cur.locality]+cost(rt), p.hops[cur.locality]+1
+			old, seen := p.cost[rt.ToLocality]
+			if seen && (d > old || (d == old && h >= p.hops[rt.ToLocality])) {
+				continue
+			}
+			p.cost[rt.ToLocality], p.hops[rt.ToLocality] = d, h
+			p.via[rt.ToLocality] = rt
+			heap.Push(pq, pathItem{locality: rt.ToLocality, cost: d, hops: h})
+		}
+	}
+	return p
+}
+
+// reached lists the settled localities other than the start.
+func (p paths) reached() []string {
+	var out []string
+	for loc := range p.done {
+		if loc != p.from {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
+
+// legsTo returns the legs of the journey to a settled locality, or false if it was not
+// reached.
+func (p paths) legsTo(to string) ([]models.TransportRoute, bool) {
+	if !p.done[to] {
+		return nil, false
+	}
+	legs := make([]models.TransportRoute, p.hops[to])
+	for at, i := to, len(legs)-1; at != p.from; i-- {
+		legs[i] = p.via[at]
+		at = p.via[at].FromLocality
+	}
+	return legs, true
+}
+
+type pathItem struct {
+	locality string
+	cost     float64
+	hops     int
+}
+
+// pathQueue is a min-heap of pathItems by cost, then hops.
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int { return len(q) }
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].hops < q[j].hops
+}
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// leg is one route of a journey with its travel time.
+type leg struct {
+	models.TransportRoute
+	TravelMin float64 `json:"travel_time_min"`
+}
+
+// journey is a route between two localities, possibly over several legs.
+type journey struct {
+	Legs          []leg   `json:"legs"`
+	TotalDistance float64 `json:"total_distance"`
+	TotalFare     float64 `json:"total_fare"`
+	TotalTimeMin  float64 `json:"total_time_min"`
+	Transfers     int     `json:"transfers"`
+}
+
+// newJourney totals legs, timed by bus during period including the wait at each boarding.
+func newJourney(routes []models.TransportRoute, period fare.Period) journey {
+	j := journey{Legs: []leg{}}
+	for i, rt := range routes {
+		t := fare.LegMinutes(fare.Bus, period, rt.Distance)
+		j.Legs = append(j.Legs, leg{TransportRoute: rt, TravelMin: round1(t)})
+		j.TotalDistance += rt.Distance
+		j.TotalFare += rt.Fare
+		j.TotalTimeMin += t
+		if i > 0 {
+			j.Transfers++
+		}
+	}
+	j.TotalDistance = round1(j.TotalDistance)
+	j.TotalFare = math.Round(j.TotalFare*100) / 100
+	j.TotalTimeMin = round1(j.TotalTimeMin)
+	return j
+}
+
+func round1(v float64) float64 { return math.Round(v*10) / 10 }
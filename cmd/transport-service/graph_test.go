@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// edge is a route written as "from>to" with its distance and fare.
+type edge struct {
+	path           string
+	distance, fare float64
+}
+
+// graphOf builds a routeGraph from edges, keeping each locality's routes in the order given.
+func graphOf(edges ...edge) routeGraph {
+	g := routeGraph{}
+	for i, e := range edges {
+		from, to, _ := strings.Cut(e.path, ">")
+		g[from] = append(g[from], models.TransportRoute{ID: i + 1, FromLocality: from, ToLocality: to, Distance: e.distance, Fare: e.fare})
+	}
+	return g
+}
+
+// stops is the localities a journey passes through, joined by ">".
+func stops(legs []models.TransportRoute) string {
+	if len(legs) == 0 {
+		return ""
+	}
+	out := []string{legs[0].FromLocality}
+	for _, l := range legs {
+		out = append(out, l.ToLocality)
+	}
+	return strings.Join(out, ">")
+}
+
+func TestShortestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    []edge
+		from, to string
+		by       string
+		want     string
+		found    bool
+	}{
+		{
+			name:  "two legs beat a longer direct route",
+			edges: []edge{{"A>B", 10, 30}, {"A>C", 3, 10}, {"C>B", 3, 10}},
+			from:  "A", to: "B", by: byDistance,
+			want: "A>C>B", found: true,
+		},
+		{
+			name:  "three legs",
+			edges: []edge{{"A>B", 2, 10}, {"B>C", 2, 10}, {"C>D", 2, 10}, {"A>D", 9, 25}, {"B>D", 5, 15}},
+			from:  "A", to: "D", by: byDistance,
+			want: "A>B>C>D", found: true,
+		},
+		{
+			name:  "fare and distance disagree: by fare",
+			edges: []edge{{"A>B", 4, 40}, {"A>C", 3, 10}, {"C>B", 3, 10}},
+			from:  "A", to: "B", by: byFare,
+			want: "A>C>B", found: true,
+		},
+		{
+			name:  "fare and distance disagree: by distance",
+			edges: []edge{{"A>B", 4, 40}, {"A>C", 3, 10}, {"C>B", 3, 10}},
+			from:  "A", to: "B", by: byDistance,
+			want: "A>B", found: true,
+		},
+		{
+			name:  "tie goes to the direct route",
+			edges: []edge{{"A>C", 1, 10}, {"C>D", 3, 10}, {"A>D", 4, 20}},
+			from:  "A", to: "D", by: byDistance,
+			want: "A>D", found: true,
+		},
+		{
+			name: "tie goes to fewer legs found later",
+			// D is first reached over A>X>W>D at distance 4 in three legs; A>Y>D also costs 4
+			// over two and replaces it.
+			edges: []edge{{"A>X", 1, 10}, {"X>W", 1, 10}, {"W>D", 2, 10}, {"A>Y", 3, 10}, {"Y>D", 1, 10}},
+			from:  "A", to: "D", by: byDistance,
+			want: "A>Y>D", found: true,
+		},
+		{
+			name:  "routes are one-way",
+			edges: []edge{{"A>B", 1, 10}},
+			from:  "B", to: "A", by: byDistance,
+			found: false,
+		},
+		{
+			name:  "disconnected",
+			edges: []edge{{"A>B", 1, 10}, {"C>D", 1, 10}},
+			from:  "A", to: "D", by: byDistance,
+			found: false,
+		},
+		{
+			name:  "same locality",
+			edges: []edge{{"A>B", 1, 10}},
+			from:  "A", to: "A", by: byDistance,
+			want: "", found: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := costFunc(tt.by, fare.AllDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			legs, found := graphOf(tt.edges...).shortestPath(tt.from, tt.to, cost)
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+			if got := stops(legs); got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDijkstraReachesAll(t *testing.T) {
+	g := graphOf(edge{"A>B", 1, 10}, edge{"B>C", 1, 10}, edge{"C>A", 1, 10}, edge{"D>A", 1, 10})
+	cost, _ := costFunc(byDistance, fare.AllDay)
+	p := g.dijkstra("A", "", cost)
+
+	got := p.reached()
+	sort.Strings(got)
+	if strings.Join(got, ",") != "B,C" {
+		t.Errorf("reached() = %v, want [B C]", got)
+	}
+	if p.cost["C"] != 2 || p.hops["C"] != 2 {
+		t.Errorf("C: cost %v over %d legs, want 2 over 2", p.cost["C"], p.hops["C"])
+	}
+}
+
+func TestCostFuncUnknown(t *testing.T) {
+	if _, err := costFunc("comfort", fare.AllDay); err == nil {
+		t.Error("costFunc(comfort) succeeded, want an error")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// routeImport imports transport_routes rows: from_locality, to_locality (registered names
+// or slugs), distance, fare. A route that already exists (same endpoints) is updated.
+var routeImport = importer.Spec[models.TransportRoute]{
+	Parse: func(row *importer.Row) models.TransportRoute {
+		rt := models.TransportRoute{
+			FromLocality: row.String("from_locality", true),
+			ToLocality:   row.String("to_locality", true),
+			Distance:     row.Float("distance"),
+			Fare:         row.Float("fare"),
+		}
+		rt.FromLocality = registeredName(row, "from_locality", rt.FromLocality)
+		rt.ToLocality = registeredName(row, "to_locality", rt.ToLocality)
+		if rt.FromLocality != "" && rt.FromLocality == rt.ToLocality {
+			row.Errorf("to_locality", "must differ from from_locality")
+		}
+		if rt.Distance <= 0 {
+			row.Errorf("distance", "must be positive")
+		}
+		if rt.Fare < 0 {
+			row.Errorf("fare", "must not be negative")
+		}
+		return rt
+	},
+	Insert: func(tx *sql.Tx, routes []models.TransportRoute) error {
+		for _, rt := range routes {
+			res, err := tx.Exec(`
+				UPDATE transport_routes SET distance = $3, fare = $4
+				WHERE from_locality = $1 AND to_locality = $2`,
+				rt.FromLocality, rt.ToLocality, rt.Distance, rt.Fare)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				continue
+			}
+			_, err = tx.Exec(`
+				INSERT INTO transport_routes (from_locality, to_locality, distance, fare)
+				VALUES ($1, $2, $3, $4)`, rt.FromLocality, rt.ToLocality, rt.Distance, rt.Fare)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// registeredName resolves a row's locality to its registered name, recording an error on
+// field if it isn't registered.
+func registeredName(row *importer.Row, field, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	l, err := localities.Resolve(ref)
+	if errors.Is(err, locality.ErrUnknown) {
+		row.Errorf(field, "unknown locality %q", ref)
+		return ref
+	}
+	if err != nil {
+		row.Errorf(field, "%v", err)
+		return ref
+	}
+	return l.Name
+}
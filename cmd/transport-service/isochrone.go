@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// defaultBands are the band thresholds in minutes when ?bands= is not given.
+var defaultBands = []int{15, 30, 45}
+
+const (
+	maxBands   = 8
+	maxBandMin = 240
+	// bandBufferKm pads each band's hull so a band reaching one or two localities is still
+	// an area.
+	bandBufferKm = 0.4
+)
+
+type zone struct {
+	ToLocality string  `json:"to_locality"`
+	Distance   float64 `json:"distance_km"`
+	Fare       float64 `json:"fare"`
+	TravelMin  float64 `json:"travel_time_min"`
+	Legs       int     `json:"legs"`
+	Zone       string  `json:"time_zone"`
+}
+
+// band is every locality reachable within MaxMin minutes, with the polygon around them and
+// the origin. Bands nest: each includes the localities of the bands inside it.
+type band struct {
+	MaxMin     int          `json:"max_min"`
+	Name       string       `json:"time_zone"`
+	Localities []string     `json:"localities"`
+	InBand     int          `json:"destinations_in_band"`
+	Polygon    [][2]float64 `json:"polygon,omitempty"`
+}
+
+// handleIsochrone serves GET /isochrone: how long it takes to reach every locality from
+// one over the route graph by ?mode= (bus by default) at the speeds of ?period=, grouped
+// into bands of at most ?bands= minutes (default 15,30,45). Each band carries the
+// localities it reaches and a hull polygon around them; with ?format=geojson the bands are
+// polygons and the localities points.
+func handleIsochrone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	asGeoJSON, err := geo.WantGeoJSON(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	from := q.Get("from")
+	if from == "" {
+		http.Error(w, "from required", http.StatusBadRequest)
+		return
+	}
+	mode := fare.Bus
+	if v := q.Get("mode"); v != "" {
+		if mode, err = fare.ParseMode(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	period, err := fare.ParsePeriod(q.Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	thresholds := defaultBands
+	if v := q.Get("bands"); v != "" {
+		if thresholds, err = parseBands(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	params, err := fareParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fromLoc, ok := resolveLocality(w, from)
+	if !ok {
+		return
+	}
+	graph, err := loadGraph()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	localities, err := locality.List(conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	zones := reachable(graph, fromLoc.Name, mode, period, params, thresholds)
+	bands := isochroneBands(fromLoc, zones, localities, thresholds)
+
+	if asGeoJSON {
+		isochroneGeoJSON(fromLoc, zones, bands, localities).Write(w)
+		return
+	}
+	reached := map[string]bool{fromLoc.Name: true}
+	for _, z := range zones {
+		reached[z.ToLocality] = true
+	}
+	unreachable := []string{}
+	for _, l := range localities {
+		if !reached[l.Name] {
+			unreachable = append(unreachable, l.Name)
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":         fromLoc.Name,
+		"mode":         mode,
+		"period":       period,
+		"bands":        bands,
+		"destinations": zones,
+		"unreachable":  unreachable,
+	})
+}
+
+// parseBands reads comma-separated band thresholds in minutes and sorts them.
+func parseBands(s string) ([]int, error) {
+	seen := map[int]bool{}
+	var out []int
+	for _, p := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n <= 0 || n > maxBandMin {
+			return nil, fmt.Errorf("bands must be comma-separated minutes between 1 and %d", maxBandMin)
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	if len(out) > maxBands {
+		return nil, fmt.Errorf("at most %d bands", maxBands)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// reachable times the fastest journey by mode from one locality to every other the graph
+// reaches, nearest first. The bus waits at each boarding; other modes ride the shortest
+// road path door to door.
+func reachable(g routeGraph, from string, mode fare.Mode, period fare.Period, params fare.Params, thresholds []int) []zone {
+	paths := g.dijkstra(from, "", func(rt models.TransportRoute) float64 {
+		return fare.LegMinutes(mode, period, rt.Distance)
+	})
+
+	zones := []zone{}
+	for _, to := range paths.reached() {
+		legs, _ := paths.legsTo(to)
+		z := zone{ToLocality: to, Legs: len(legs)}
+		for _, l := range legs {
+			z.Distance += l.Distance
+		}
+		z.Distance = round1(z.Distance)
+		for _, quote := range fare.Quotes(legs, params, period) {
+			if quote.Mode == mode {
+				z.Fare, z.TravelMin = quote.Fare, quote.TimeMin
+			}
+		}
+		z.Zone = bandName(z.TravelMin, thresholds)
+		zones = append(zones, z)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].TravelMin != zones[j].TravelMin {
+			return zones[i].TravelMin < zones[j].TravelMin
+		}
+		return zones[i].ToLocality < zones[j].ToLocality
+	})
+	return zones
+}
+
+// bandName labels a travel time with the first band it fits, or "<last>+ min" beyond all
+// of them.
+func bandName(travelMin float64, thresholds []int) string {
+	for _, t := range thresholds {
+		if travelMin <= float64(t) {
+			return fmt.Sprintf("%d min", t)
+		}
+	}
+	return fmt.Sprintf("%d+ min", thresholds[len(thresholds)-1])
+}
+
+// isochroneBands builds one band per threshold. A band's polygon is the convex hull of the
+// origin and every locality within it, padded by bandBufferKm; an empty band has none.
+func isochroneBands(origin models.Locality, zones []zone, localities []models.Locality, thresholds []int) []band {
+	at := locationsByName(localities)
+	out := make([]band, 0, len(thresholds))
+	prev := 0.0
+	for _, t := range thresholds {
+		b := band{MaxMin: t, Name: fmt.Sprintf("%d min", t), Localities: []string{}}
+		reach := []geo.Point{{Lat: origin.Lat, Lon: origin.Lon}}
+		for _, z := range zones {
+			if z.TravelMin > float64(t) {
+				continue
+			}
+			b.Localities = append(b.Localities, z.ToLocality)
+			reach = append(reach, at[z.ToLocality])
+			if z.TravelMin > prev {
+				b.InBand++
+			}
+		}
+		if len(b.Localities) > 0 {
+			b.Polygon = geo.Ring(geo.BufferedHull(reach, bandBufferKm))
+		}
+		out = append(out, b)
+		prev = float64(t)
+	}
+	return out
+}
+
+func locationsByName(localities []models.Locality) map[string]geo.Point {
+	at := map[string]geo.Point{}
+	for _, l := range localities {
+		at[l.Name] = geo.Point{Lat: l.Lat, Lon: l.Lon}
+	}
+	return at
+}
+
+// isochroneGeoJSON draws the bands that reach at least one locality, innermost first, then
+// the origin and destination points.
+func isochroneGeoJSON(origin models.Locality, zones []zone, bands []band, localities []models.Locality) *geo.FeatureCollection {
+	at := locationsByName(localities)
+	fc := geo.NewCollection()
+	for _, b := range bands {
+		if b.Polygon == nil {
+			continue
+		}
+		fc.AddPolygon(b.Polygon, map[string]interface{}{
+			"kind": "band", "time_zone": b.Name, "max_min": b.MaxMin,
+			"destinations_in_band": b.InBand, "localities": b.Localities,
+		})
+	}
+
+	fc.AddPoint(geo.Point{Lat: origin.Lat, Lon: origin.Lon}, map[string]interface{}{"kind": "origin", "locality": origin.Name})
+	for _, z := range zones {
+		fc.AddPoint(at[z.ToLocality], map[string]interface{}{
+			"kind": "destination", "locality": z.ToLocality, "distance_km": z.Distance,
+			"fare": z.Fare, "travel_time_min": z.TravelMin, "legs": z.Legs, "time_zone": z.Zone,
+		})
+	}
+	return fc
+}
@@ -3,71 +3,85 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
+	"strconv"
 
+	"rent-cost-analyzer/internal/auth"
 	"rent-cost-analyzer/internal/db"
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/internal/importer"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/internal/seed"
 	"rent-cost-analyzer/pkg/models"
 )
 
-var conn *sql.DB
+var (
+	conn       *sql.DB
+	localities *locality.Registry
+)
 
 func main() {
+	seedConfig := seed.FromEnv()
+	seedConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if _, err := auth.Secret(); err != nil {
+		log.Fatal("auth:", err)
+	}
 	c, err := db.Open()
 	if err != nil {
 		log.Fatal("db open:", err)
 	}
 	defer c.Close()
 	conn = c
+	localities = locality.NewRegistry(conn)
 
-	initTables(conn)
-	seedMockData(conn)
+	if n, err := db.MigrateUp(conn, "transport"); err != nil {
+		log.Fatal("migrate:", err)
+	} else if n > 0 {
+		log.Printf("applied %d transport migration(s)", n)
+	}
+	if _, err := seed.IfEmpty(conn, mockData, seedConfig); err != nil {
+		log.Println("seed routes:", err)
+	}
 
-	http.HandleFunc("/route", handleRoute)
-	http.HandleFunc("/isochrone", handleIsochrone)
+	http.HandleFunc("/route", auth.Require(auth.ScopeRead, handleRoute))
+	http.HandleFunc("/isochrone", auth.Require(auth.ScopeRead, handleIsochrone))
+	http.HandleFunc("/import", auth.Require(auth.ScopeWrite, importer.Handler(conn, routeImport)))
+	http.HandleFunc("/admin/reset-and-seed", auth.Require(auth.ScopeAdmin, seed.Handler(conn, seedConfig, mockData)))
 	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 
 	log.Println("transport-service listening on :8084")
 	log.Fatal(http.ListenAndServe(":8084", nil))
 }
 
-func initTables(c *sql.DB) {
-	_, err := c.Exec(`
-		CREATE TABLE IF NOT EXISTS transport_routes (
-			id SERIAL PRIMARY KEY,
-			from_locality VARCHAR(100),
-			to_locality VARCHAR(100),
-			distance DECIMAL(10,2),
-			fare DECIMAL(10,2)
-		);
-	`)
-	if err != nil {
-		log.Fatal("create table:", err)
-	}
-}
-
-func seedMockData(c *sql.DB) {
-	var count int
-	c.QueryRow("SELECT COUNT(*) FROM transport_routes").Scan(&count)
-	if count > 0 {
-		return
-	}
-
-	localities := []string{"Ashta Central", "Railway Colony", "Industrial Area", "Market Ward", "Gandhi Nagar", "Nehru Colony"}
-
-	for _, from := range localities {
-		for _, to := range localities {
-			if from != to {
-				distance := rand.Float64()*8 + 2
-				fare := distance * 8
-				c.Exec(`INSERT INTO transport_routes (from_locality, to_locality, distance, fare) 
-					VALUES ($1, $2, $3, $4)`, from, to, distance, fare)
+// mockData regenerates a route each way between every pair of configured localities.
+var mockData = seed.Reset{
+	Tables: []string{"transport_routes"},
+	Fill: func(tx *sql.Tx, c seed.Config) (int, error) {
+		locs, err := seed.Localities(tx, c)
+		if err != nil {
+			return 0, err
+		}
+		routes := seed.Routes(c, locs)
+		for _, rt := range routes {
+			if _, err := tx.Exec(`INSERT INTO transport_routes (from_locality, to_locality, distance, fare)
+				VALUES ($1, $2, $3, $4)`, rt.FromLocality, rt.ToLocality, rt.Distance, rt.Fare); err != nil {
+				return 0, err
 			}
 		}
-	}
+		return len(routes), nil
+	},
 }
 
+// handleRoute serves GET /route: the cheapest journey from one locality to another over the
+// route graph, by fare (default), distance or time (?by=), possibly over several legs, with
+// what the journey costs by bus, auto, two-wheeler and on foot. Times use the speeds of
+// ?period= (peak, off_peak or a departure time; the all-day average by default).
 func handleRoute(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -82,93 +96,111 @@ func handleRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var route models.TransportRoute
-	err := conn.QueryRow(`
-		SELECT id, from_locality, to_locality, distance, fare
-		FROM transport_routes 
-		WHERE from_locality LIKE $1 AND to_locality LIKE $2 
-		LIMIT 1
-	`, "%"+from+"%", "%"+to+"%").Scan(&route.ID, &route.FromLocality, &route.ToLocality, &route.Distance, &route.Fare)
-
-	if err == sql.ErrNoRows {
-		// Return a placeholder so CLI can use commute distance
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"found": false,
-			"from":  from,
-			"to":    to,
-		})
+	fromLoc, ok := resolveLocality(w, from)
+	if !ok {
 		return
 	}
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	toLoc, ok := resolveLocality(w, to)
+	if !ok {
 		return
 	}
+	from, to = fromLoc.Name, toLoc.Name
 
-	dailyCost := route.Fare * 2
-	monthlyCost := dailyCost * 26
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"found":        true,
-		"route":        route,
-		"daily_cost":   dailyCost,
-		"monthly_cost": monthlyCost,
-	})
-}
-
-func handleIsochrone(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = byFare
+	}
+	period, err := fare.ParsePeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cost, err := costFunc(by, period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
 
-	from := r.URL.Query().Get("from")
-	if from == "" {
-		http.Error(w, "from required", http.StatusBadRequest)
+	params, err := fareParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	rows, err := conn.Query(`
-		SELECT to_locality, distance, fare
-		FROM transport_routes
-		WHERE from_locality LIKE $1
-		ORDER BY distance
-	`, "%"+from+"%")
+	graph, err := loadGraph()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	type zone struct {
-		ToLocality string  `json:"to_locality"`
-		Distance   float64 `json:"distance_km"`
-		Fare       float64 `json:"fare"`
-		TravelMin  int     `json:"travel_time_min"`
-		Zone       string  `json:"time_zone"`
+	legs, ok := graph.shortestPath(from, to, cost)
+	if !ok {
+		// Return a placeholder so CLI can use commute distance
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"found": false,
+			"from":  from,
+			"to":    to,
+			"by":    by,
+		})
+		return
 	}
 
-	var result []zone
-	for rows.Next() {
-		var toLoc string
-		var dist, fare float64
-		rows.Scan(&toLoc, &dist, &fare)
+	j := newJourney(legs, period)
+	dailyCost := j.TotalFare * 2
+	monthlyCost := dailyCost * fare.WorkingDays
+	quotes := fare.Quotes(legs, params, period)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"found":  true,
+		"from":   from,
+		"to":     to,
+		"by":     by,
+		"period": period,
+		// route sums the journey as one from/to pair for clients that ignore legs.
+		"route": models.TransportRoute{
+			FromLocality: from, ToLocality: to, Distance: j.TotalDistance, Fare: j.TotalFare,
+		},
+		"legs":           j.Legs,
+		"total_distance": j.TotalDistance,
+		"total_fare":     j.TotalFare,
+		"total_time_min": j.TotalTimeMin,
+		"transfers":      j.Transfers,
+		"daily_cost":     dailyCost,
+		"monthly_cost":   monthlyCost,
+		"fare_params":    params,
+		"modes":          quotes,
+		"cheapest":       fare.Cheapest(quotes).Mode,
+		"fastest":        fare.Fastest(quotes).Mode,
+	})
+}
 
-		travelMin := int(dist / 25 * 60)
-		zoneStr := "15 min"
-		if travelMin > 30 {
-			zoneStr = "45+ min"
-		} else if travelMin > 15 {
-			zoneStr = "30 min"
+// fareParams reads two-wheeler costs from ?mileage_kmpl= and ?petrol_price=, defaulting to
+// fare.DefaultParams.
+func fareParams(r *http.Request) (fare.Params, error) {
+	p := fare.DefaultParams()
+	q := r.URL.Query()
+	for key, dst := range map[string]*float64{"mileage_kmpl": &p.MileageKmpl, "petrol_price": &p.PetrolPrice} {
+		if v := q.Get(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return p, fmt.Errorf("%s must be a number", key)
+			}
+			*dst = f
 		}
-
-		result = append(result, zone{
-			ToLocality: toLoc,
-			Distance:   dist,
-			Fare:       fare,
-			TravelMin:  travelMin,
-			Zone:       zoneStr,
-		})
 	}
+	return p, p.Validate()
+}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{"from": from, "destinations": result})
+// resolveLocality looks up a registered locality by name or slug, writing 404 for an
+// unknown one and 500 for a lookup failure.
+func resolveLocality(w http.ResponseWriter, ref string) (models.Locality, bool) {
+	l, err := localities.Resolve(ref)
+	if errors.Is(err, locality.ErrUnknown) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return l, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return l, false
+	}
+	return l, true
 }
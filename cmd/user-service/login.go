@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"rent-cost-analyzer/internal/auth"
+)
+
+// adminUserID is the login id of the operator account whose password is AUTH_ADMIN_PASSWORD.
+const adminUserID = 0
+
+// handleLogin serves POST /login: it checks a user id and password and issues a bearer
+// token. Profiles get read, plus write when an admin has granted can_write; the admin
+// login gets every scope. A client may ask for a subset (e.g. read-only).
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		UserID   int      `json:"user_id"`
+		Password string   `json:"password"`
+		Scopes   []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	granted := []string{auth.ScopeRead}
+	if req.UserID == adminUserID {
+		admin := os.Getenv("AUTH_ADMIN_PASSWORD")
+		if admin == "" || subtle.ConstantTimeCompare([]byte(req.Password), []byte(admin)) != 1 {
+			writeLoginFailed(w)
+			return
+		}
+		granted = append(granted, auth.ScopeWrite, auth.ScopeAdmin)
+	} else {
+		var hash sql.NullString
+		var canWrite bool
+		err := conn.QueryRow("SELECT password_hash, can_write FROM users WHERE id = $1", req.UserID).Scan(&hash, &canWrite)
+		if err == sql.ErrNoRows || (err == nil && !auth.CheckPassword(req.Password, hash.String)) {
+			writeLoginFailed(w)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if canWrite {
+			granted = append(granted, auth.ScopeWrite)
+		}
+	}
+
+	scopes := granted
+	if len(req.Scopes) > 0 {
+		scopes = nil
+		for _, s := range req.Scopes {
+			if !contains(granted, s) {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "scope not allowed: " + s})
+				return
+			}
+			scopes = append(scopes, s)
+		}
+	}
+
+	secret, err := auth.Secret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, claims, err := auth.Issue(secret, strconv.Itoa(req.UserID), scopes, auth.DefaultTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":      token,
+		"token_type": "Bearer",
+		"user_id":    req.UserID,
+		"scopes":     claims.Scopes,
+		"expires_at": claims.ExpiresAt,
+	})
+}
+
+func writeLoginFailed(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": "invalid user id or password"})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
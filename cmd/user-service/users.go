@@ -0,0 +1,282 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rent-cost-analyzer/internal/auth"
+	"rent-cost-analyzer/pkg/models"
+)
+
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+	minPasswordLen    = 8
+)
+
+// validateUser returns a field -> message map (empty when valid).
+func validateUser(u models.UserProfile) map[string]string {
+	errs := map[string]string{}
+	if strings.TrimSpace(u.Name) == "" {
+		errs["name"] = "required"
+	}
+	if u.Income < 0 {
+		errs["income"] = "must not be negative"
+	}
+	if u.FamilySize < 1 {
+		errs["family_size"] = "must be at least 1"
+	}
+	if u.CommuteDistance < 0 {
+		errs["commute_distance"] = "must not be negative"
+	}
+	if u.Password != "" && len(u.Password) < minPasswordLen {
+		errs["password"] = "must be at least " + strconv.Itoa(minPasswordLen) + " characters"
+	}
+	return errs
+}
+
+// canModify reports whether the request's token may read or change user id: its own
+// profile, or any profile with the admin scope.
+func canModify(r *http.Request, id int) bool {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		return false
+	}
+	return claims.Subject == strconv.Itoa(id) || claims.HasScope(auth.ScopeAdmin)
+}
+
+// isAdmin reports whether the request's token has the admin scope. Only admins change
+// can_write.
+func isAdmin(r *http.Request) bool {
+	claims, ok := auth.FromContext(r.Context())
+	return ok && claims.HasScope(auth.ScopeAdmin)
+}
+
+func writeValidationError(w http.ResponseWriter, errs map[string]string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "validation failed", "fields": errs})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": "user not found"})
+}
+
+func getUser(c *sql.DB, id int) (models.UserProfile, error) {
+	var u models.UserProfile
+	err := c.QueryRow(`
+		SELECT id, name, income, family_size, preferred_locale, commute_distance, COALESCE(workplace, ''), can_write
+		FROM users WHERE id = $1
+	`, id).Scan(&u.ID, &u.Name, &u.Income, &u.FamilySize, &u.PreferredLocale, &u.CommuteDistance, &u.Workplace, &u.CanWrite)
+	return u, err
+}
+
+// handleUsers serves GET /users (paginated with limit/offset) and POST /users. Profiles
+// hold household income, so only admins list them all; anyone else gets just their own.
+func handleUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		if !isAdmin(r) {
+			listOwnProfile(w, r)
+			return
+		}
+		limit, offset := defaultUsersLimit, 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxUsersLimit {
+				http.Error(w, "invalid limit: must be 1-"+strconv.Itoa(maxUsersLimit), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		if v := r.URL.Query().Get("offset"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid offset", http.StatusBadRequest)
+				return
+			}
+			offset = n
+		}
+
+		var total int
+		if err := conn.QueryRow("SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows, err := conn.Query(`
+			SELECT id, name, income, family_size, preferred_locale, commute_distance, COALESCE(workplace, ''), can_write
+			FROM users ORDER BY id LIMIT $1 OFFSET $2
+		`, limit, offset)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		list := []models.UserProfile{}
+		for rows.Next() {
+			var u models.UserProfile
+			if err := rows.Scan(&u.ID, &u.Name, &u.Income, &u.FamilySize, &u.PreferredLocale, &u.CommuteDistance, &u.Workplace, &u.CanWrite); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			list = append(list, u)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"users":  list,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+		return
+
+	case http.MethodPost:
+		var u models.UserProfile
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		errs := validateUser(u)
+		if u.Password == "" {
+			errs["password"] = "required"
+		}
+		if len(errs) > 0 {
+			writeValidationError(w, errs)
+			return
+		}
+		hash, err := auth.HashPassword(u.Password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Registration is open, so it never grants write access; an admin does that later.
+		u.CanWrite = false
+		err = conn.QueryRow(`
+			INSERT INTO users (name, income, family_size, preferred_locale, commute_distance, workplace, password_hash)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+			RETURNING id
+		`, u.Name, u.Income, u.FamilySize, u.PreferredLocale, u.CommuteDistance, u.Workplace, hash).Scan(&u.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		u.Password = ""
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(u)
+		return
+	}
+
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// listOwnProfile answers GET /users for a non-admin token with the caller's profile alone.
+func listOwnProfile(w http.ResponseWriter, r *http.Request) {
+	list := []models.UserProfile{}
+	claims, _ := auth.FromContext(r.Context())
+	if id, err := strconv.Atoi(claims.Subject); err == nil {
+		u, err := getUser(conn, id)
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			list = append(list, u)
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users":  list,
+		"total":  len(list),
+		"limit":  defaultUsersLimit,
+		"offset": 0,
+	})
+}
+
+// handleUserByID serves GET, PUT, PATCH and DELETE on /users/{id}.
+func handleUserByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/users/"))
+	if err != nil || id <= 0 {
+		writeNotFound(w)
+		return
+	}
+
+	// Check ownership before the lookup so a 404 doesn't reveal which other ids exist.
+	if !canModify(r, id) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "can only access your own profile"})
+		return
+	}
+
+	existing, err := getUser(conn, id)
+	if err == sql.ErrNoRows {
+		writeNotFound(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(existing)
+
+	case http.MethodPut, http.MethodPatch:
+		// PUT replaces the profile; PATCH decodes onto the stored one so omitted fields keep their values.
+		u := models.UserProfile{}
+		if r.Method == http.MethodPatch {
+			u = existing
+		}
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		u.ID = id
+		if !isAdmin(r) {
+			u.CanWrite = existing.CanWrite
+		}
+		if errs := validateUser(u); len(errs) > 0 {
+			writeValidationError(w, errs)
+			return
+		}
+		// An empty password keeps the stored hash.
+		hash := ""
+		if u.Password != "" {
+			if hash, err = auth.HashPassword(u.Password); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		_, err := conn.Exec(`
+			UPDATE users
+			SET name = $1, income = $2, family_size = $3, preferred_locale = $4, commute_distance = $5,
+				workplace = NULLIF($6, ''), password_hash = COALESCE(NULLIF($7, ''), password_hash), can_write = $8
+			WHERE id = $9
+		`, u.Name, u.Income, u.FamilySize, u.PreferredLocale, u.CommuteDistance, u.Workplace, hash, u.CanWrite, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		u.Password = ""
+		json.NewEncoder(w).Encode(u)
+
+	case http.MethodDelete:
+		if _, err := conn.Exec("DELETE FROM users WHERE id = $1", id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
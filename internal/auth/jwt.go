@@ -0,0 +1,138 @@
+// Package auth issues and verifies HMAC-signed (HS256) JWT bearer tokens and provides the
+// HTTP middleware every service uses to enforce read/write scopes.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes carried by tokens.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// DefaultTTL is how long issued tokens stay valid.
+const DefaultTTL = 24 * time.Hour
+
+// devSecret is public, so anyone can mint tokens signed with it. It is only used when
+// AUTH_INSECURE_DEV_SECRET=1 opts in for local development.
+const devSecret = "rent-cost-analyzer-dev-secret"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+	ErrNoSecret     = errors.New("AUTH_SECRET is not set (set AUTH_INSECURE_DEV_SECRET=1 to use the public development secret locally)")
+
+	warnOnce sync.Once
+)
+
+// Claims is the token payload.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Scopes    []string `json:"scopes"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// HasScope reports whether the claims grant scope. Admin tokens grant every scope.
+func (c Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret returns the signing key from AUTH_SECRET. Without it, the development secret is
+// used only when AUTH_INSECURE_DEV_SECRET=1; otherwise it is ErrNoSecret and no token can
+// be issued or verified. Services check it at startup and refuse to run without one.
+func Secret() ([]byte, error) {
+	if s := os.Getenv("AUTH_SECRET"); s != "" {
+		return []byte(s), nil
+	}
+	if os.Getenv("AUTH_INSECURE_DEV_SECRET") == "1" {
+		warnOnce.Do(func() { log.Println("auth: AUTH_SECRET not set; using the insecure development secret") })
+		return []byte(devSecret), nil
+	}
+	return nil, ErrNoSecret
+}
+
+var jwtHeader = b64(mustJSON(map[string]string{"alg": "HS256", "typ": "JWT"}))
+
+// Issue signs a token for subject with the given scopes, valid for ttl.
+func Issue(secret []byte, subject string, scopes []string, ttl time.Duration) (string, Claims, error) {
+	if len(secret) == 0 {
+		return "", Claims{}, ErrNoSecret
+	}
+	now := time.Now()
+	c := Claims{Subject: subject, Scopes: scopes, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", c, err
+	}
+	signingInput := jwtHeader + "." + b64(payload)
+	return signingInput + "." + sign(secret, signingInput), c, nil
+}
+
+// Verify checks the token's signature and expiry and returns its claims.
+func Verify(secret []byte, token string) (Claims, error) {
+	var c Claims
+	if len(secret) == 0 {
+		return c, ErrNoSecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return c, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	hb, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(hb, &header) != nil || header.Alg != "HS256" {
+		return c, ErrInvalidToken
+	}
+	expected := sign(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return c, ErrInvalidToken
+	}
+
+	pb, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(pb, &c) != nil {
+		return c, ErrInvalidToken
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return c, ErrExpiredToken
+	}
+	return c, nil
+}
+
+func sign(secret []byte, input string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return b64(mac.Sum(nil))
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// forge builds a token with the given header and claims, signed with secret over
+// whatever the header claims its algorithm is.
+func forge(header map[string]string, c Claims, secret []byte) string {
+	input := b64(mustJSON(header)) + "." + b64(mustJSON(c))
+	return input + "." + sign(secret, input)
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("test-secret")
+	valid, _, err := Issue(secret, "7", []string{ScopeRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := Issue(secret, "7", []string{ScopeRead}, -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	live := Claims{Subject: "7", Scopes: []string{ScopeRead}, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	admin := live
+	admin.Scopes = []string{ScopeAdmin}
+
+	tests := []struct {
+		name   string
+		secret []byte
+		token  string
+		want   error
+	}{
+		{"valid", secret, valid, nil},
+		{"wrong secret", []byte("other-secret"), valid, ErrInvalidToken},
+		{"tampered payload", secret, parts[0] + "." + b64(mustJSON(admin)) + "." + parts[2], ErrInvalidToken},
+		{"tampered signature", secret, parts[0] + "." + parts[1] + "." + sign([]byte("x"), parts[0]+"."+parts[1]), ErrInvalidToken},
+		{"signature stripped", secret, parts[0] + "." + parts[1] + ".", ErrInvalidToken},
+		{"expired", secret, expired, ErrExpiredToken},
+		{"alg none", secret, b64(mustJSON(map[string]string{"alg": "none", "typ": "JWT"})) + "." + b64(mustJSON(live)) + ".", ErrInvalidToken},
+		{"alg HS512", secret, forge(map[string]string{"alg": "HS512", "typ": "JWT"}, live, secret), ErrInvalidToken},
+		{"alg RS256", secret, forge(map[string]string{"alg": "RS256", "typ": "JWT"}, live, secret), ErrInvalidToken},
+		{"alg missing", secret, forge(map[string]string{"typ": "JWT"}, live, secret), ErrInvalidToken},
+		{"two parts", secret, parts[0] + "." + parts[1], ErrInvalidToken},
+		{"header not base64", secret, "!!." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"empty", secret, "", ErrInvalidToken},
+		{"no secret", nil, valid, ErrNoSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Verify(tt.secret, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (c.Subject != "7" || !c.HasScope(ScopeRead) || c.HasScope(ScopeWrite)) {
+				t.Errorf("Verify() claims = %+v", c)
+			}
+		})
+	}
+}
+
+func TestIssueWithoutSecret(t *testing.T) {
+	if _, _, err := Issue(nil, "7", []string{ScopeRead}, time.Hour); !errors.Is(err, ErrNoSecret) {
+		t.Fatalf("Issue() error = %v, want ErrNoSecret", err)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		insecure string
+		want     string
+		wantErr  error
+	}{
+		{"set", "s3cret", "", "s3cret", nil},
+		{"set wins over dev opt-in", "s3cret", "1", "s3cret", nil},
+		{"unset", "", "", "", ErrNoSecret},
+		{"unset with dev opt-in", "", "1", devSecret, nil},
+		{"opt-in must be 1", "", "true", "", ErrNoSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AUTH_SECRET", tt.secret)
+			t.Setenv("AUTH_INSECURE_DEV_SECRET", tt.insecure)
+			got, err := Secret()
+			if !errors.Is(err, tt.wantErr) || string(got) != tt.want {
+				t.Errorf("Secret() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeRead}, ScopeRead, true},
+		{[]string{ScopeRead}, ScopeWrite, false},
+		{[]string{ScopeRead, ScopeWrite}, ScopeWrite, true},
+		{[]string{ScopeAdmin}, ScopeWrite, true},
+		{nil, ScopeRead, false},
+	}
+	for _, tt := range tests {
+		if got := (Claims{Scopes: tt.scopes}).HasScope(tt.scope); got != tt.want {
+			t.Errorf("Claims{Scopes: %v}.HasScope(%q) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
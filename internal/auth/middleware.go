@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// FromContext returns the claims of the request's verified token.
+func FromContext(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(ctxKey{}).(Claims)
+	return c, ok
+}
+
+// Require wraps h so it only runs for requests bearing a valid token with scope.
+// Missing or bad tokens get 401; valid tokens without the scope get 403.
+func Require(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+		secret, err := Secret()
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "auth not configured"})
+			return
+		}
+		claims, err := Verify(secret, token)
+		if err != nil {
+			unauthorized(w, err.Error())
+			return
+		}
+		if !claims.HasScope(scope) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "token lacks " + scope + " scope"})
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, claims)))
+	}
+}
+
+// ByMethod requires the read scope for GET and HEAD and the write scope for everything
+// else; it is for routes that serve both reads and writes.
+func ByMethod(h http.HandlerFunc) http.HandlerFunc {
+	read, write := Require(ScopeRead, h), Require(ScopeWrite, h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			read(w, r)
+			return
+		}
+		write(w, r)
+	}
+}
+
+// Forward copies the incoming request's Authorization header onto an outgoing request, so
+// service-to-service calls act with the caller's token.
+func Forward(from, to *http.Request) {
+	if v := from.Header.Get("Authorization"); v != "" {
+		to.Header.Set("Authorization", v)
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(h[7:]), true
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="rent-cost-analyzer"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
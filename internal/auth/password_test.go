@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// PBKDF2-HMAC-SHA256 known-answer vectors: the RFC 6070 inputs (that RFC lists SHA-1
+// outputs) with their SHA-256 results, and the two RFC 7914 section 11 vectors, each
+// truncated to keyLen bytes.
+func TestPBKDF2Vectors(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iter           int
+		want           string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1"},
+		{"pass\x00word", "sa\x00lt", 4096, "89b69d0516f829893c696226650a86878c029ac13ee276509d5ae58b6466a724"},
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2([]byte(tt.password), []byte(tt.salt), tt.iter))
+		if got != tt.want {
+			t.Errorf("pbkdf2(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iter, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+
+	tests := []struct {
+		name, password, encoded string
+		want                    bool
+	}{
+		{"match", "correct horse", hash, true},
+		{"wrong password", "correct horse!", hash, false},
+		{"empty password", "", hash, false},
+		{"other scheme", "correct horse", "bcrypt$" + strings.Join(parts[1:], "$"), false},
+		{"bad iterations", "correct horse", strings.Join([]string{parts[0], "0", parts[2], parts[3]}, "$"), false},
+		{"fewer iterations", "correct horse", strings.Join([]string{parts[0], "1000", parts[2], parts[3]}, "$"), false},
+		{"bad salt", "correct horse", strings.Join([]string{parts[0], parts[1], "!!", parts[3]}, "$"), false},
+		{"truncated", "correct horse", strings.Join(parts[:3], "$"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPassword(tt.password, tt.encoded); got != tt.want {
+				t.Errorf("CheckPassword(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashPasswordSalted(t *testing.T) {
+	a, _ := HashPassword("same")
+	b, _ := HashPassword("same")
+	if a == b {
+		t.Error("two hashes of the same password are identical; the salt is not random")
+	}
+}
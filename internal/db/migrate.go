@@ -0,0 +1,292 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations live in migrations/<service>/NNNN_name.up.sql with a matching .down.sql.
+// Versions are per service and applied in order; each one runs in its own transaction
+// together with its schema_migrations row, so a failed migration leaves nothing behind.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+// migrationLockKey is the pg_advisory_lock key held while migrating, so services starting
+// at the same time apply migrations one after another instead of racing.
+const migrationLockKey = 7_461_230_011
+
+// Migration is one numbered schema change for a service.
+type Migration struct {
+	Service string
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration and when it was applied (zero if pending).
+type MigrationState struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Applied reports whether the migration has been run.
+func (s MigrationState) Applied() bool { return !s.AppliedAt.IsZero() }
+
+// MigrationServices lists the services that have migrations, in alphabetical order.
+func MigrationServices() ([]string, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() {
+			out = append(out, e.Name())
+		}
+	}
+	return out, nil
+}
+
+// Migrations returns a service's migrations ordered by version.
+func Migrations(service string) ([]Migration, error) {
+	dir := path.Join("migrations", service)
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for service %q", service)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		num, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("%s/%s: want NNNN_name.%s.sql", service, name, direction)
+		}
+		body, err := fs.ReadFile(migrationsFS, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Service: service, Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("%s: version %d used by %q and %q", service, version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%s: migration %04d_%s has no up file", service, m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// migrationDeps lists the migration sets a service's schema references, e.g. foreign keys
+// into the locality registry. MigrateUp applies them first.
+var migrationDeps = map[string][]string{
+	"rental":    {"locality"},
+	"transport": {"locality"},
+}
+
+// MigrateUp applies every pending migration for service, after those of the sets it
+// depends on, and returns how many ran.
+func MigrateUp(c *sql.DB, service string) (int, error) {
+	total := 0
+	for _, dep := range migrationDeps[service] {
+		n, err := MigrateUp(c, dep)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	n, err := migrateUp(c, service)
+	return total + n, err
+}
+
+func migrateUp(c *sql.DB, service string) (int, error) {
+	all, err := Migrations(service)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = withMigrationLock(c, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn, service)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := runMigration(conn, m, m.Up, true); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// MigrateDown reverts the latest steps applied migrations for service, newest first, and
+// returns how many were reverted.
+func MigrateDown(c *sql.DB, service string, steps int) (int, error) {
+	all, err := Migrations(service)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = withMigrationLock(c, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn, service)
+		if err != nil {
+			return err
+		}
+		for i := len(all) - 1; i >= 0 && n < steps; i-- {
+			m := all[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("%s: migration %04d_%s has no down file", service, m.Version, m.Name)
+			}
+			if err := runMigration(conn, m, m.Down, false); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// MigrationStatus returns every migration for service with its applied time, if any.
+func MigrationStatus(c *sql.DB, service string) ([]MigrationState, error) {
+	all, err := Migrations(service)
+	if err != nil {
+		return nil, err
+	}
+	var out []MigrationState
+	err = withMigrationLock(c, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn, service)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			out = append(out, MigrationState{Migration: m, AppliedAt: applied[m.Version]})
+		}
+		return nil
+	})
+	return out, err
+}
+
+// withMigrationLock runs fn on one connection holding the session-level advisory lock, after
+// making sure schema_migrations exists.
+func withMigrationLock(c *sql.DB, fn func(*sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := c.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			service VARCHAR(50) NOT NULL,
+			version INT NOT NULL,
+			name VARCHAR(200) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (service, version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func appliedVersions(conn *sql.Conn, service string) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(),
+		"SELECT version, applied_at FROM schema_migrations WHERE service = $1", service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		out[v] = at
+	}
+	return out, rows.Err()
+}
+
+// runMigration executes one direction of m and records it, in a single transaction.
+func runMigration(conn *sql.Conn, m Migration, body string, up bool) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("%s %04d_%s %s: %w", m.Service, m.Version, m.Name, direction, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (service, version, name) VALUES ($1, $2, $3)",
+			m.Service, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM schema_migrations WHERE service = $1 AND version = $2", m.Service, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
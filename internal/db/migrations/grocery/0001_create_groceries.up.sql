@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS groceries (
+    id SERIAL PRIMARY KEY,
+    item VARCHAR(100),
+    price DECIMAL(10,2),
+    source VARCHAR(50)
+);
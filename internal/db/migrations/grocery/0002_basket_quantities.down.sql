@@ -0,0 +1,3 @@
+ALTER TABLE groceries DROP COLUMN IF EXISTS child_weekly_qty;
+ALTER TABLE groceries DROP COLUMN IF EXISTS adult_weekly_qty;
+ALTER TABLE groceries DROP COLUMN IF EXISTS unit;
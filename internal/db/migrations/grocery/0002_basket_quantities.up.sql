@@ -0,0 +1,17 @@
+-- Each row is one pack (price per unit); a household buys adult/child weekly quantities of it.
+ALTER TABLE groceries ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT 'pack';
+ALTER TABLE groceries ADD COLUMN IF NOT EXISTS adult_weekly_qty DECIMAL(8,3) NOT NULL DEFAULT 1;
+ALTER TABLE groceries ADD COLUMN IF NOT EXISTS child_weekly_qty DECIMAL(8,3) NOT NULL DEFAULT 0.5;
+
+UPDATE groceries g SET unit = v.unit, adult_weekly_qty = v.adult, child_weekly_qty = v.child
+FROM (VALUES
+    ('Rice (1kg)', 'kg', 1.0, 0.5),
+    ('Wheat Flour (1kg)', 'kg', 1.2, 0.6),
+    ('Cooking Oil (1L)', 'L', 0.25, 0.1),
+    ('Milk (1L)', 'L', 2.5, 2.0),
+    ('Vegetables (weekly)', 'basket', 0.4, 0.25),
+    ('Lentils (1kg)', 'kg', 0.3, 0.15),
+    ('Sugar (1kg)', 'kg', 0.25, 0.15),
+    ('Tea/Coffee', 'pack', 0.1, 0)
+) AS v(item, unit, adult, child)
+WHERE g.item = v.item;
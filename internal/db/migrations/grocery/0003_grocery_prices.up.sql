@@ -0,0 +1,15 @@
+-- One row per observed price of an item at a source; the latest row per (item, source) is
+-- that vendor's current price.
+CREATE TABLE IF NOT EXISTS grocery_prices (
+    id SERIAL PRIMARY KEY,
+    item_id INT NOT NULL REFERENCES groceries(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    price DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    observed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS grocery_prices_latest_idx ON grocery_prices (item_id, source, observed_at DESC);
+
+-- Carry each item's existing single price over as its first observation.
+INSERT INTO grocery_prices (item_id, source, price)
+SELECT g.id, g.source, g.price FROM groceries g
+WHERE g.price > 0 AND NOT EXISTS (SELECT 1 FROM grocery_prices p WHERE p.item_id = g.id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS grocery_price_history_observation_idx;
+ALTER INDEX IF EXISTS grocery_price_history_latest_idx RENAME TO grocery_prices_latest_idx;
+ALTER TABLE IF EXISTS grocery_price_history RENAME TO grocery_prices;
@@ -0,0 +1,6 @@
+-- grocery_prices already holds one row per observation; name it for what it is and make
+-- re-ingesting the same observation an update rather than a duplicate.
+ALTER TABLE IF EXISTS grocery_prices RENAME TO grocery_price_history;
+ALTER INDEX IF EXISTS grocery_prices_latest_idx RENAME TO grocery_price_history_latest_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS grocery_price_history_observation_idx
+    ON grocery_price_history (item_id, source, observed_at);
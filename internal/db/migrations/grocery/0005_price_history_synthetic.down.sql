@@ -0,0 +1 @@
+ALTER TABLE grocery_price_history DROP COLUMN IF EXISTS synthetic;
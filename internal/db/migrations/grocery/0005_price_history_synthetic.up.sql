@@ -0,0 +1,3 @@
+-- Rows generated by the seed (derived vendor quotes and the back-filled monthly series)
+-- are marked so they are never mistaken for observed prices.
+ALTER TABLE grocery_price_history ADD COLUMN IF NOT EXISTS synthetic BOOLEAN NOT NULL DEFAULT FALSE;
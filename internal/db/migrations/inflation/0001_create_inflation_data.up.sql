@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS inflation_data (
+    id SERIAL PRIMARY KEY,
+    month VARCHAR(20),
+    rate DECIMAL(5,2),
+    category VARCHAR(50)
+);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS inflation_data_period_category_key;
+
+ALTER TABLE inflation_data ADD COLUMN IF NOT EXISTS month VARCHAR(20);
+UPDATE inflation_data SET month = to_char(period, 'Mon YYYY');
+
+ALTER TABLE inflation_data
+    ALTER COLUMN category DROP NOT NULL,
+    ALTER COLUMN rate DROP NOT NULL,
+    DROP COLUMN period;
+
+-- Restore the rows the up migration set aside, with their original ids and months.
+INSERT INTO inflation_data (id, month, rate, category)
+SELECT id, month, rate, category FROM inflation_data_quarantine
+ON CONFLICT (id) DO NOTHING;
+DROP TABLE IF EXISTS inflation_data_quarantine;
@@ -0,0 +1,52 @@
+-- month was free text ("Jan 2025"), so ORDER BY month sorted alphabetically. period holds
+-- the first day of the month as a DATE. Rows that can't be converted are not deleted: they
+-- move to inflation_data_quarantine with the reason, where they can be fixed and
+-- re-imported, and the down migration puts them back.
+CREATE TABLE IF NOT EXISTS inflation_data_quarantine (
+    id INT PRIMARY KEY,
+    month VARCHAR(20),
+    rate DECIMAL(5,2),
+    category VARCHAR(50),
+    reason TEXT NOT NULL,
+    quarantined_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE inflation_data ADD COLUMN IF NOT EXISTS period DATE;
+
+UPDATE inflation_data SET period = to_date(TRIM(month), 'Mon YYYY')
+WHERE TRIM(month) ~* '^[a-z]{3} [0-9]{4}$';
+
+UPDATE inflation_data SET period = to_date(TRIM(month), 'YYYY-MM')
+WHERE period IS NULL AND TRIM(month) ~ '^[0-9]{4}-[0-9]{2}$';
+
+WITH moved AS (
+    DELETE FROM inflation_data
+    WHERE period IS NULL OR category IS NULL OR rate IS NULL
+    RETURNING id, month, rate, category, period
+)
+INSERT INTO inflation_data_quarantine (id, month, rate, category, reason)
+SELECT id, month, rate, category,
+       CASE WHEN period IS NULL THEN 'month is not "Mon YYYY" or YYYY-MM'
+            WHEN category IS NULL THEN 'no category'
+            ELSE 'no rate' END
+FROM moved;
+
+-- One rate per month and category: the most recently inserted stays, older ones move.
+WITH moved AS (
+    DELETE FROM inflation_data a
+    USING inflation_data b
+    WHERE a.period = b.period AND LOWER(a.category) = LOWER(b.category) AND a.id < b.id
+    RETURNING a.id, a.month, a.rate, a.category
+)
+INSERT INTO inflation_data_quarantine (id, month, rate, category, reason)
+SELECT DISTINCT id, month, rate, category, 'superseded by a later row for the same month and category'
+FROM moved;
+
+ALTER TABLE inflation_data
+    ALTER COLUMN period SET NOT NULL,
+    ALTER COLUMN category SET NOT NULL,
+    ALTER COLUMN rate SET NOT NULL,
+    DROP COLUMN month;
+
+CREATE UNIQUE INDEX IF NOT EXISTS inflation_data_period_category_key
+    ON inflation_data (period, LOWER(category));
@@ -0,0 +1,20 @@
+-- Registered localities. boundary is a closed GeoJSON-style ring of [lon, lat] pairs.
+CREATE TABLE IF NOT EXISTS localities (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    boundary JSONB,
+    population INT CHECK (population >= 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS localities_lower_name_idx ON localities (LOWER(name));
+
+INSERT INTO localities (name, slug, lat, lon, boundary, population) VALUES
+    ('Ashta Central', 'ashta-central', 23.0198, 76.7224, '[[76.728,23.0228],[76.7224,23.0258],[76.7168,23.0228],[76.7168,23.0168],[76.7224,23.0138],[76.728,23.0168],[76.728,23.0228]]', 14500),
+    ('Railway Colony', 'railway-colony', 23.0420, 76.7050, '[[76.7121,23.0458],[76.705,23.0495],[76.6979,23.0458],[76.6979,23.0383],[76.705,23.0345],[76.7121,23.0383],[76.7121,23.0458]]', 8200),
+    ('Industrial Area', 'industrial-area', 22.9850, 76.7600, '[[76.7703,22.9905],[76.76,22.996],[76.7497,22.9905],[76.7497,22.9795],[76.76,22.974],[76.7703,22.9795],[76.7703,22.9905]]', 5400),
+    ('Market Ward', 'market-ward', 23.0260, 76.7330, '[[76.7377,23.0285],[76.733,23.031],[76.7283,23.0285],[76.7283,23.0235],[76.733,23.021],[76.7377,23.0235],[76.7377,23.0285]]', 11800),
+    ('Gandhi Nagar', 'gandhi-nagar', 23.0550, 76.7450, '[[76.7525,23.059],[76.745,23.063],[76.7375,23.059],[76.7375,23.051],[76.745,23.047],[76.7525,23.051],[76.7525,23.059]]', 9600),
+    ('Nehru Colony', 'nehru-colony', 23.0000, 76.6950, '[[76.7025,23.004],[76.695,23.008],[76.6875,23.004],[76.6875,22.996],[76.695,22.992],[76.7025,22.996],[76.7025,23.004]]', 7300)
+ON CONFLICT DO NOTHING;
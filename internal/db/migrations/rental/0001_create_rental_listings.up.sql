@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS rental_listings (
+    id SERIAL PRIMARY KEY,
+    locality VARCHAR(100),
+    rent DECIMAL(10,2),
+    bedrooms INT,
+    sqft INT,
+    classification VARCHAR(20),
+    distance DECIMAL(10,2),
+    lat DECIMAL(10,6),
+    lon DECIMAL(10,6)
+);
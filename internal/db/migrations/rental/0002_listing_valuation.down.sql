@@ -0,0 +1,2 @@
+ALTER TABLE rental_listings DROP COLUMN IF EXISTS z_score;
+ALTER TABLE rental_listings DROP COLUMN IF EXISTS expected_rent;
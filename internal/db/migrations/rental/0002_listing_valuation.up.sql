@@ -0,0 +1,2 @@
+ALTER TABLE rental_listings ADD COLUMN IF NOT EXISTS expected_rent DECIMAL(10,2);
+ALTER TABLE rental_listings ADD COLUMN IF NOT EXISTS z_score DECIMAL(6,2);
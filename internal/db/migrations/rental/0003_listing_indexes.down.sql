@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS rental_listings_rent_id_idx;
+DROP INDEX IF EXISTS rental_listings_locality_idx;
@@ -0,0 +1,3 @@
+-- Locality filters compare LOWER(locality); the default listing sort pages by (rent, id).
+CREATE INDEX IF NOT EXISTS rental_listings_locality_idx ON rental_listings (LOWER(locality));
+CREATE INDEX IF NOT EXISTS rental_listings_rent_id_idx ON rental_listings (rent, id);
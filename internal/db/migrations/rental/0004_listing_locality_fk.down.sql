@@ -0,0 +1 @@
+ALTER TABLE rental_listings DROP CONSTRAINT IF EXISTS rental_listings_locality_fkey;
@@ -0,0 +1,22 @@
+-- Listings must name a registered locality. Existing names are trimmed and matched to the
+-- registry case-insensitively; names the registry doesn't know are registered at the mean
+-- position of their listings so existing data survives the new foreign key.
+UPDATE rental_listings SET locality = NULLIF(TRIM(locality), '')
+WHERE locality IS DISTINCT FROM NULLIF(TRIM(locality), '');
+
+INSERT INTO localities (name, slug, lat, lon)
+SELECT MIN(locality),
+       TRIM(BOTH '-' FROM regexp_replace(LOWER(MIN(locality)), '[^a-z0-9]+', '-', 'g')),
+       COALESCE(AVG(lat), 23.0198), COALESCE(AVG(lon), 76.7224)
+FROM rental_listings
+WHERE locality IS NOT NULL AND LOWER(locality) NOT IN (SELECT LOWER(name) FROM localities)
+GROUP BY LOWER(locality)
+ON CONFLICT DO NOTHING;
+
+UPDATE rental_listings l SET locality = loc.name
+FROM localities loc
+WHERE LOWER(l.locality) = LOWER(loc.name) AND l.locality <> loc.name;
+
+ALTER TABLE rental_listings
+    ADD CONSTRAINT rental_listings_locality_fkey
+    FOREIGN KEY (locality) REFERENCES localities (name) ON UPDATE CASCADE;
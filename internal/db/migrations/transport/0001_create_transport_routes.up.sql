@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS transport_routes (
+    id SERIAL PRIMARY KEY,
+    from_locality VARCHAR(100),
+    to_locality VARCHAR(100),
+    distance DECIMAL(10,2),
+    fare DECIMAL(10,2)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE transport_routes
+    DROP CONSTRAINT IF EXISTS transport_routes_from_locality_fkey,
+    DROP CONSTRAINT IF EXISTS transport_routes_to_locality_fkey;
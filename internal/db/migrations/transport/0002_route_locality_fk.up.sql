@@ -0,0 +1,28 @@
+-- Both ends of a route must be registered localities. Existing names are trimmed and
+-- matched case-insensitively; unknown ones are registered at the town centre, since routes
+-- carry no coordinates.
+UPDATE transport_routes
+SET from_locality = NULLIF(TRIM(from_locality), ''), to_locality = NULLIF(TRIM(to_locality), '')
+WHERE from_locality IS DISTINCT FROM NULLIF(TRIM(from_locality), '')
+   OR to_locality IS DISTINCT FROM NULLIF(TRIM(to_locality), '');
+
+INSERT INTO localities (name, slug, lat, lon)
+SELECT MIN(name), TRIM(BOTH '-' FROM regexp_replace(LOWER(MIN(name)), '[^a-z0-9]+', '-', 'g')), 23.0198, 76.7224
+FROM (SELECT from_locality AS name FROM transport_routes UNION SELECT to_locality FROM transport_routes) n
+WHERE name IS NOT NULL AND LOWER(name) NOT IN (SELECT LOWER(name) FROM localities)
+GROUP BY LOWER(name)
+ON CONFLICT DO NOTHING;
+
+UPDATE transport_routes r SET from_locality = loc.name
+FROM localities loc
+WHERE LOWER(r.from_locality) = LOWER(loc.name) AND r.from_locality <> loc.name;
+
+UPDATE transport_routes r SET to_locality = loc.name
+FROM localities loc
+WHERE LOWER(r.to_locality) = LOWER(loc.name) AND r.to_locality <> loc.name;
+
+ALTER TABLE transport_routes
+    ADD CONSTRAINT transport_routes_from_locality_fkey
+    FOREIGN KEY (from_locality) REFERENCES localities (name) ON UPDATE CASCADE,
+    ADD CONSTRAINT transport_routes_to_locality_fkey
+    FOREIGN KEY (to_locality) REFERENCES localities (name) ON UPDATE CASCADE;
@@ -0,0 +1,12 @@
+-- Route fares were a flat ₹8/km; reprice those still on that rate to BCLL's distance slabs
+-- (see internal/fare). Fares entered any other way are left alone.
+UPDATE transport_routes
+SET fare = CASE
+    WHEN distance <= 2 THEN 10
+    WHEN distance <= 5 THEN 15
+    WHEN distance <= 10 THEN 20
+    WHEN distance <= 15 THEN 25
+    WHEN distance <= 20 THEN 30
+    ELSE 30 + CEIL((distance - 20) / 5) * 5
+END
+WHERE fare = distance * 8;
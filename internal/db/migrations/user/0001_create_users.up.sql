@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100),
+    income DECIMAL(12,2),
+    family_size INT,
+    preferred_locale VARCHAR(100),
+    commute_distance DECIMAL(10,2)
+);
@@ -0,0 +1 @@
+-- The sequence also backs ids created by 0001; nothing to undo.
@@ -0,0 +1,6 @@
+-- Databases created before multi-user support have "id INTEGER DEFAULT 1"; give them a
+-- sequence starting after the existing rows. On a fresh table this just re-points SERIAL's
+-- own sequence.
+CREATE SEQUENCE IF NOT EXISTS users_id_seq OWNED BY users.id;
+ALTER TABLE users ALTER COLUMN id SET DEFAULT nextval('users_id_seq');
+SELECT setval('users_id_seq', COALESCE(MAX(id), 0) + 1, false) FROM users;
@@ -0,0 +1,2 @@
+-- The locality a user commutes to, used to price the commute in rental's /compare.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS workplace VARCHAR(100);
@@ -0,0 +1,4 @@
+-- Whether the profile's tokens carry the write scope, which changes shared data (listings,
+-- prices, routes, localities, models). Only an admin grants it; existing profiles start
+-- without it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS can_write BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,189 @@
+// Package fare prices a commute by mode: BCLL bus fare slabs, the auto-rickshaw meter,
+// two-wheeler fuel and walking. Fares are in rupees for one trip; monthly costs assume a
+// round trip on each of WorkingDays days.
+package fare
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+// Mode is a way of making a trip.
+type Mode string
+
+const (
+	Bus        Mode = "bus"
+	Auto       Mode = "auto"
+	TwoWheeler Mode = "two_wheeler"
+	Walk       Mode = "walk"
+)
+
+// Modes lists every mode in the order quotes are returned.
+var Modes = []Mode{Bus, Auto, TwoWheeler, Walk}
+
+// WorkingDays is the number of commuting days in a month.
+const WorkingDays = 26
+
+// busSlabs are BCLL's distance slabs: the fare for a trip up to UpToKm. Trips past the last
+// slab pay busExtraFare for each further busExtraKm or part of it.
+var busSlabs = []struct {
+	UpToKm float64
+	Fare   float64
+}{
+	{2, 10},
+	{5, 15},
+	{10, 20},
+	{15, 25},
+	{20, 30},
+}
+
+const (
+	busExtraKm   = 5.0
+	busExtraFare = 5.0
+
+	// Auto-rickshaw meter: the minimum fare covers autoBaseKm, then autoPerKm.
+	autoBaseKm   = 1.5
+	autoBaseFare = 30.0
+	autoPerKm    = 15.0
+
+	// MaxWalkKm is the longest trip walking is recommended for.
+	MaxWalkKm = 3.0
+)
+
+// Params are the two-wheeler running costs.
+type Params struct {
+	MileageKmpl float64 `json:"mileage_kmpl"`
+	PetrolPrice float64 `json:"petrol_price"`
+}
+
+// DefaultParams is a 110 cc scooter at the Madhya Pradesh petrol price, overridden by
+// TWO_WHEELER_MILEAGE_KMPL and PETROL_PRICE when set.
+func DefaultParams() Params {
+	p := Params{MileageKmpl: 45, PetrolPrice: 108.5}
+	if v, err := strconv.ParseFloat(os.Getenv("TWO_WHEELER_MILEAGE_KMPL"), 64); err == nil && v > 0 {
+		p.MileageKmpl = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PETROL_PRICE"), 64); err == nil && v > 0 {
+		p.PetrolPrice = v
+	}
+	return p
+}
+
+// Validate reports a parameter that cannot price a trip.
+func (p Params) Validate() error {
+	if p.MileageKmpl <= 0 {
+		return fmt.Errorf("mileage_kmpl must be positive")
+	}
+	if p.PetrolPrice <= 0 {
+		return fmt.Errorf("petrol_price must be positive")
+	}
+	return nil
+}
+
+// BusFare is the BCLL fare for one boarding of distKm.
+func BusFare(distKm float64) float64 {
+	for _, s := range busSlabs {
+		if distKm <= s.UpToKm {
+			return s.Fare
+		}
+	}
+	last := busSlabs[len(busSlabs)-1]
+	return last.Fare + math.Ceil((distKm-last.UpToKm)/busExtraKm)*busExtraFare
+}
+
+// AutoFare is the auto-rickshaw meter fare for distKm.
+func AutoFare(distKm float64) float64 {
+	if distKm <= autoBaseKm {
+		return autoBaseFare
+	}
+	return round2(autoBaseFare + (distKm-autoBaseKm)*autoPerKm)
+}
+
+// FuelCost is the petrol used riding distKm on a two-wheeler.
+func FuelCost(distKm float64, p Params) float64 {
+	return round2(distKm / p.MileageKmpl * p.PetrolPrice)
+}
+
+// Quote is the cost and time of a trip by one mode. Practical is false for walks longer
+// than MaxWalkKm; such quotes are never recommended.
+type Quote struct {
+	Mode        Mode    `json:"mode"`
+	Fare        float64 `json:"fare"`
+	TimeMin     float64 `json:"time_min"`
+	DailyCost   float64 `json:"daily_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
+	Practical   bool    `json:"practical"`
+}
+
+// Quotes prices a journey over legs by every mode, with times at period's speeds. The bus
+// is boarded once per leg and charged each leg's fare as stored on the route; the other
+// modes go door to door over the journey's total distance.
+func Quotes(legs []models.TransportRoute, p Params, period Period) []Quote {
+	var dist, busFare, busMin float64
+	for _, l := range legs {
+		dist += l.Distance
+		busFare += l.Fare
+		busMin += LegMinutes(Bus, period, l.Distance)
+	}
+
+	quotes := make([]Quote, 0, len(Modes))
+	for _, m := range Modes {
+		q := Quote{Mode: m, Practical: true}
+		switch m {
+		case Bus:
+			q.Fare, q.TimeMin = busFare, busMin
+		case Auto:
+			q.Fare, q.TimeMin = AutoFare(dist), LegMinutes(Auto, period, dist)
+		case TwoWheeler:
+			q.Fare, q.TimeMin = FuelCost(dist, p), LegMinutes(TwoWheeler, period, dist)
+		case Walk:
+			q.TimeMin, q.Practical = LegMinutes(Walk, period, dist), dist <= MaxWalkKm
+		}
+		if len(legs) == 0 {
+			q.Fare, q.TimeMin = 0, 0
+		}
+		q.Fare = round2(q.Fare)
+		q.TimeMin = math.Round(q.TimeMin*10) / 10
+		q.DailyCost = round2(q.Fare * 2)
+		q.MonthlyCost = round2(q.DailyCost * WorkingDays)
+		quotes = append(quotes, q)
+	}
+	return quotes
+}
+
+// Cheapest is the practical quote with the lowest fare, preferring the faster on a tie.
+func Cheapest(quotes []Quote) Quote {
+	return best(quotes, func(a, b Quote) bool {
+		if a.Fare != b.Fare {
+			return a.Fare < b.Fare
+		}
+		return a.TimeMin < b.TimeMin
+	})
+}
+
+// Fastest is the practical quote with the shortest time, preferring the cheaper on a tie.
+func Fastest(quotes []Quote) Quote {
+	return best(quotes, func(a, b Quote) bool {
+		if a.TimeMin != b.TimeMin {
+			return a.TimeMin < b.TimeMin
+		}
+		return a.Fare < b.Fare
+	})
+}
+
+func best(quotes []Quote, less func(a, b Quote) bool) Quote {
+	var out Quote
+	found := false
+	for _, q := range quotes {
+		if q.Practical && (!found || less(q, out)) {
+			out, found = q, true
+		}
+	}
+	return out
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
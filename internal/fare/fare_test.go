@@ -0,0 +1,133 @@
+package fare
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+func TestBusFare(t *testing.T) {
+	tests := []struct {
+		distKm float64
+		want   float64
+	}{
+		{0, 10},
+		{0.5, 10},
+		{2, 10},
+		{2.01, 15},
+		{5, 15},
+		{5.01, 20},
+		{10, 20},
+		{10.01, 25},
+		{15, 25},
+		{15.01, 30},
+		{20, 30},
+		// Past the last slab: Rs 5 for each further 5 km or part of it.
+		{20.01, 35},
+		{25, 35},
+		{25.01, 40},
+		{30, 40},
+		{41, 55},
+	}
+	for _, tt := range tests {
+		if got := BusFare(tt.distKm); got != tt.want {
+			t.Errorf("BusFare(%v) = %v, want %v", tt.distKm, got, tt.want)
+		}
+	}
+}
+
+func TestAutoFare(t *testing.T) {
+	tests := []struct {
+		distKm float64
+		want   float64
+	}{
+		{0, 30},
+		{1.5, 30},
+		{2, 37.5},
+		{10, 157.5},
+	}
+	for _, tt := range tests {
+		if got := AutoFare(tt.distKm); got != tt.want {
+			t.Errorf("AutoFare(%v) = %v, want %v", tt.distKm, got, tt.want)
+		}
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	p := Params{MileageKmpl: 50, PetrolPrice: 100}
+	tests := []struct {
+		name     string
+		legs     []models.TransportRoute
+		want     map[Mode]float64 // fare per mode
+		walkable bool
+		cheapest Mode
+	}{
+		{
+			name:     "short walk",
+			legs:     []models.TransportRoute{{Distance: 2, Fare: 10}},
+			want:     map[Mode]float64{Bus: 10, Auto: 37.5, TwoWheeler: 4, Walk: 0},
+			walkable: true,
+			cheapest: Walk,
+		},
+		{
+			name:     "two bus legs pay two fares",
+			legs:     []models.TransportRoute{{Distance: 4, Fare: 15}, {Distance: 6, Fare: 20}},
+			want:     map[Mode]float64{Bus: 35, Auto: 157.5, TwoWheeler: 20, Walk: 0},
+			cheapest: TwoWheeler,
+		},
+		{
+			name:     "no legs",
+			want:     map[Mode]float64{Bus: 0, Auto: 0, TwoWheeler: 0, Walk: 0},
+			walkable: true,
+			cheapest: Bus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes := Quotes(tt.legs, p, AllDay)
+			if len(quotes) != len(Modes) {
+				t.Fatalf("got %d quotes, want %d", len(quotes), len(Modes))
+			}
+			for i, q := range quotes {
+				if q.Mode != Modes[i] {
+					t.Errorf("quote %d is %s, want %s", i, q.Mode, Modes[i])
+				}
+				if q.Fare != tt.want[q.Mode] {
+					t.Errorf("%s fare = %v, want %v", q.Mode, q.Fare, tt.want[q.Mode])
+				}
+				if q.MonthlyCost != math.Round(q.Fare*2*WorkingDays*100)/100 {
+					t.Errorf("%s monthly cost = %v, want a round trip on %d days", q.Mode, q.MonthlyCost, WorkingDays)
+				}
+				if q.Mode == Walk && q.Practical != tt.walkable {
+					t.Errorf("walk practical = %v, want %v", q.Practical, tt.walkable)
+				}
+			}
+			if got := Cheapest(quotes).Mode; got != tt.cheapest {
+				t.Errorf("Cheapest() = %s, want %s", got, tt.cheapest)
+			}
+		})
+	}
+}
+
+func TestPeriodAt(t *testing.T) {
+	tests := []struct {
+		clock string
+		want  Period
+	}{
+		{"07:59", OffPeak},
+		{"08:00", Peak},
+		{"10:59", Peak},
+		{"11:00", OffPeak},
+		{"17:00", Peak},
+		{"19:59", Peak},
+		{"20:00", OffPeak},
+	}
+	for _, tt := range tests {
+		at, _ := time.Parse("15:04", tt.clock)
+		if got := PeriodAt(at); got != tt.want {
+			t.Errorf("PeriodAt(%s) = %s, want %s", tt.clock, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package fare
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a part of the day with its own traffic speeds.
+type Period string
+
+const (
+	// AllDay averages over the day; it is the default when no time is given.
+	AllDay  Period = "all_day"
+	Peak    Period = "peak"
+	OffPeak Period = "off_peak"
+)
+
+// peakHours are the morning and evening rush windows, [start, end) in hours.
+var peakHours = [][2]int{{8, 11}, {17, 20}}
+
+// speeds are average door-to-door speeds in km/h by mode and period.
+var speeds = map[Mode]map[Period]float64{
+	Bus:        {AllDay: 18, Peak: 14, OffPeak: 21},
+	Auto:       {AllDay: 22, Peak: 17, OffPeak: 26},
+	TwoWheeler: {AllDay: 28, Peak: 22, OffPeak: 32},
+	Walk:       {AllDay: 5, Peak: 5, OffPeak: 5},
+}
+
+// busWaits are the minutes spent waiting at each boarding; buses run more often at peak.
+var busWaits = map[Period]float64{AllDay: 10, Peak: 7, OffPeak: 12}
+
+// PeriodAt is the period a trip starting at t falls in.
+func PeriodAt(t time.Time) Period {
+	for _, w := range peakHours {
+		if t.Hour() >= w[0] && t.Hour() < w[1] {
+			return Peak
+		}
+	}
+	return OffPeak
+}
+
+// ParsePeriod reads a period name, or a departure time as HH:MM, with "" meaning AllDay.
+func ParsePeriod(s string) (Period, error) {
+	switch p := Period(s); p {
+	case "":
+		return AllDay, nil
+	case AllDay, Peak, OffPeak:
+		return p, nil
+	}
+	if t, err := time.Parse("15:04", s); err == nil {
+		return PeriodAt(t), nil
+	}
+	return "", fmt.Errorf("period must be %s, %s, %s or a time as HH:MM", AllDay, Peak, OffPeak)
+}
+
+// ParseMode reads a mode name.
+func ParseMode(s string) (Mode, error) {
+	for _, m := range Modes {
+		if Mode(s) == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("mode must be %s, %s, %s or %s", Bus, Auto, TwoWheeler, Walk)
+}
+
+// SpeedKmh is the average speed of mode during period.
+func SpeedKmh(m Mode, p Period) float64 { return speeds[m][p] }
+
+// BusWaitMin is the wait at each bus boarding during period.
+func BusWaitMin(p Period) float64 { return busWaits[p] }
+
+// LegMinutes is the time to ride one leg of distKm by mode, including the wait to board
+// when the mode is the bus.
+func LegMinutes(m Mode, p Period, distKm float64) float64 {
+	t := distKm / SpeedKmh(m, p) * 60
+	if m == Bus {
+		t += BusWaitMin(p)
+	}
+	return t
+}
@@ -0,0 +1,191 @@
+// Package gbm implements a small, deterministic gradient-boosted regression tree model.
+//
+// Trees are fit on the loss gradient (plain residuals for squared error, signs for
+// quantile loss) with exhaustive split search, so the same training data and
+// params always produce the same model. Models serialise to JSON for persistence.
+package gbm
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Params controls training.
+type Params struct {
+	NumTrees       int     `json:"num_trees"`
+	LearningRate   float64 `json:"learning_rate"`
+	MaxDepth       int     `json:"max_depth"`
+	MinSamplesLeaf int     `json:"min_samples_leaf"`
+	// Quantile switches the loss from squared error (0) to pinball loss for the given
+	// quantile in (0, 1), so the model predicts that quantile of y instead of its mean.
+	Quantile float64 `json:"quantile,omitempty"`
+}
+
+// WithQuantile returns a copy of p that trains a quantile model.
+func (p Params) WithQuantile(q float64) Params {
+	p.Quantile = q
+	return p
+}
+
+// DefaultParams returns settings that work for the small tabular datasets in this project.
+func DefaultParams() Params {
+	return Params{NumTrees: 100, LearningRate: 0.1, MaxDepth: 3, MinSamplesLeaf: 3}
+}
+
+// Model is a trained ensemble: Base plus the sum of every tree's output.
+type Model struct {
+	Params   Params    `json:"params"`
+	Features []string  `json:"features"`
+	Base     float64   `json:"base"`
+	Trees    []Tree    `json:"trees"`
+	Gain     []float64 `json:"gain"` // total split gain per feature, for importance
+}
+
+// Train fits a model to rows X (one column per feature name) and targets y.
+func Train(features []string, X [][]float64, y []float64, p Params) (*Model, error) {
+	if len(X) == 0 || len(X) != len(y) {
+		return nil, errors.New("gbm: need a non-empty X with one target per row")
+	}
+	for _, row := range X {
+		if len(row) != len(features) {
+			return nil, errors.New("gbm: row width does not match feature count")
+		}
+	}
+	if p.NumTrees <= 0 || p.LearningRate <= 0 || p.MaxDepth <= 0 || p.MinSamplesLeaf <= 0 {
+		return nil, errors.New("gbm: params must be positive")
+	}
+	if p.Quantile < 0 || p.Quantile >= 1 {
+		return nil, errors.New("gbm: quantile must be in (0, 1), or 0 for squared loss")
+	}
+
+	m := &Model{Params: p, Features: features, Gain: make([]float64, len(features))}
+	if p.Quantile > 0 {
+		m.Base = quantile(y, p.Quantile)
+	} else {
+		for _, v := range y {
+			m.Base += v
+		}
+		m.Base /= float64(len(y))
+	}
+
+	pred := make([]float64, len(y))
+	for i := range pred {
+		pred[i] = m.Base
+	}
+	resid := make([]float64, len(y))
+	grad := make([]float64, len(y))
+	idx := make([]int, len(y))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	for t := 0; t < p.NumTrees; t++ {
+		for i := range y {
+			resid[i] = y[i] - pred[i]
+			grad[i] = resid[i]
+			if p.Quantile > 0 {
+				// Negative gradient of the pinball loss.
+				grad[i] = p.Quantile
+				if resid[i] < 0 {
+					grad[i] = p.Quantile - 1
+				}
+			}
+		}
+		b := &builder{X: X, y: grad, resid: resid, p: p, gain: m.Gain}
+		b.grow(idx, 0)
+		tree := Tree{Nodes: b.nodes}
+		m.Trees = append(m.Trees, tree)
+		for i := range y {
+			pred[i] += tree.predict(X[i])
+		}
+	}
+	return m, nil
+}
+
+// Predict returns the model output for one row.
+func (m *Model) Predict(x []float64) float64 {
+	out := m.Base
+	for i := range m.Trees {
+		out += m.Trees[i].predict(x)
+	}
+	return out
+}
+
+// PredictQuantiles returns the outputs of models trained for increasing quantiles on one
+// row, sorted. Quantile models are trained independently and can cross where data is
+// thin; sorting (monotone rearrangement) keeps the quantiles ordered without moving any
+// value outside the range the models predicted.
+func PredictQuantiles(models []*Model, x []float64) []float64 {
+	out := make([]float64, len(models))
+	for i, m := range models {
+		out[i] = m.Predict(x)
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// FeatureImportance returns each feature's share of the total split gain (sums to 1).
+func (m *Model) FeatureImportance() map[string]float64 {
+	var total float64
+	for _, g := range m.Gain {
+		total += g
+	}
+	imp := make(map[string]float64, len(m.Features))
+	for i, f := range m.Features {
+		if total > 0 {
+			imp[f] = m.Gain[i] / total
+		} else {
+			imp[f] = 0
+		}
+	}
+	return imp
+}
+
+// RMSE is the root mean squared error of the model over X, y.
+func (m *Model) RMSE(X [][]float64, y []float64) float64 {
+	if len(y) == 0 {
+		return 0
+	}
+	var sq float64
+	for i := range y {
+		d := m.Predict(X[i]) - y[i]
+		sq += d * d
+	}
+	return math.Sqrt(sq / float64(len(y)))
+}
+
+// R2 is the coefficient of determination of the model over X, y.
+func (m *Model) R2(X [][]float64, y []float64) float64 {
+	if len(y) == 0 {
+		return 0
+	}
+	var mean float64
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+	var ssRes, ssTot float64
+	for i := range y {
+		d := m.Predict(X[i]) - y[i]
+		ssRes += d * d
+		ssTot += (y[i] - mean) * (y[i] - mean)
+	}
+	if ssTot == 0 {
+		return 1
+	}
+	return 1 - ssRes/ssTot
+}
+
+// quantile returns the q-quantile of v using linear interpolation between order statistics.
+func quantile(v []float64, q float64) float64 {
+	if len(v) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), v...)
+	sort.Float64s(s)
+	pos := q * float64(len(s)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return s[lo] + (s[hi]-s[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,133 @@
+package gbm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// dataset draws n rows of y = f(x) + noise(x)·N(0,1) for x spread over [0, 10), from a
+// fixed seed so every run trains the same models.
+func dataset(n int, f, noise func(x float64) float64) ([][]float64, []float64) {
+	r := rand.New(rand.NewSource(1))
+	X := make([][]float64, n)
+	y := make([]float64, n)
+	for i := range X {
+		x := 10 * float64(i) / float64(n)
+		X[i] = []float64{x}
+		y[i] = f(x) + noise(x)*r.NormFloat64()
+	}
+	return X, y
+}
+
+// TestQuantileOrdering trains P10/P50/P90 models on each dataset and checks that the
+// rearranged predictions are ordered and split the training rows roughly 10/40/40/10.
+func TestQuantileOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		f, noise func(x float64) float64
+	}{
+		{"constant noise", func(x float64) float64 { return 1000 + 50*x }, func(float64) float64 { return 40 }},
+		{"noise grows with x", func(x float64) float64 { return 1000 + 50*x }, func(x float64) float64 { return 5 + 20*x }},
+		{"step", func(x float64) float64 { return 500 + 400*math.Floor(x/2.5) }, func(float64) float64 { return 60 }},
+		{"no noise", func(x float64) float64 { return 20 * x }, func(float64) float64 { return 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			X, y := dataset(300, tt.f, tt.noise)
+			p := DefaultParams()
+			var models []*Model
+			for _, q := range []float64{0.1, 0.5, 0.9} {
+				m, err := Train([]string{"x"}, X, y, p.WithQuantile(q))
+				if err != nil {
+					t.Fatal(err)
+				}
+				models = append(models, m)
+			}
+
+			const eps = 1e-9
+			var below, above, belowMedian int
+			for i, row := range X {
+				q := PredictQuantiles(models, row)
+				p10, p50, p90 := q[0], q[1], q[2]
+				if p10 > p50 || p50 > p90 {
+					t.Fatalf("x=%.2f: P10 %.2f, P50 %.2f, P90 %.2f not ordered", row[0], p10, p50, p90)
+				}
+				if y[i] < p10-eps {
+					below++
+				}
+				if y[i] > p90+eps {
+					above++
+				}
+				if y[i] < p50-eps {
+					belowMedian++
+				}
+			}
+			// On its own training data each tail should hold roughly a tenth of the rows.
+			for side, n := range map[string]int{"below P10": below, "above P90": above} {
+				if share := float64(n) / float64(len(y)); share > 0.2 {
+					t.Errorf("%s: %.0f%% of rows, want about 10%%", side, share*100)
+				}
+			}
+			if share := float64(belowMedian) / float64(len(y)); share > 0.65 {
+				t.Errorf("below P50: %.0f%% of rows, want about 50%%", share*100)
+			}
+		})
+	}
+}
+
+func TestPredictQuantilesKeepsValues(t *testing.T) {
+	// Three constant models that cross: the "P10" model predicts the most.
+	models := []*Model{{Base: 30}, {Base: 10}, {Base: 20}}
+	got := PredictQuantiles(models, []float64{0})
+	want := []float64{10, 20, 30}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("PredictQuantiles() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	tests := []struct {
+		v    []float64
+		q    float64
+		want float64
+	}{
+		{[]float64{3, 1, 2}, 0.5, 2},
+		{[]float64{1, 2, 3, 4}, 0.5, 2.5},
+		{[]float64{10, 20, 30, 40, 50}, 0.1, 14},
+		{[]float64{10, 20, 30, 40, 50}, 0.9, 46},
+		{[]float64{7}, 0.9, 7},
+		{nil, 0.5, 0},
+	}
+	for _, tt := range tests {
+		if got := quantile(tt.v, tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("quantile(%v, %v) = %v, want %v", tt.v, tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestTrainRejects(t *testing.T) {
+	X := [][]float64{{1}, {2}, {3}}
+	y := []float64{1, 2, 3}
+	tests := []struct {
+		name     string
+		features []string
+		X        [][]float64
+		y        []float64
+		p        Params
+	}{
+		{"empty", []string{"x"}, nil, nil, DefaultParams()},
+		{"target count", []string{"x"}, X, y[:2], DefaultParams()},
+		{"row width", []string{"x", "z"}, X, y, DefaultParams()},
+		{"zero trees", []string{"x"}, X, y, Params{LearningRate: 0.1, MaxDepth: 3, MinSamplesLeaf: 1}},
+		{"quantile 1", []string{"x"}, X, y, DefaultParams().WithQuantile(1)},
+		{"negative quantile", []string{"x"}, X, y, DefaultParams().WithQuantile(-0.1)},
+	}
+	for _, tt := range tests {
+		if _, err := Train(tt.features, tt.X, tt.y, tt.p); err == nil {
+			t.Errorf("%s: Train() succeeded, want an error", tt.name)
+		}
+	}
+}
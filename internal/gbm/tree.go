@@ -0,0 +1,128 @@
+package gbm
+
+import "sort"
+
+// Node is one tree node. Leaves have Left == -1 and carry Value, already scaled by the
+// learning rate.
+type Node struct {
+	Feature   int     `json:"f"`
+	Threshold float64 `json:"t"`
+	Left      int     `json:"l"`
+	Right     int     `json:"r"`
+	Value     float64 `json:"v"`
+}
+
+// Tree is a regression tree stored as a flat node slice; Nodes[0] is the root.
+type Tree struct {
+	Nodes []Node `json:"nodes"`
+}
+
+func (t *Tree) predict(x []float64) float64 {
+	n := 0
+	for t.Nodes[n].Left != -1 {
+		if x[t.Nodes[n].Feature] <= t.Nodes[n].Threshold {
+			n = t.Nodes[n].Left
+		} else {
+			n = t.Nodes[n].Right
+		}
+	}
+	return t.Nodes[n].Value
+}
+
+// builder grows one tree. Splits are chosen on y (the negative gradient); leaf values
+// are computed from resid, the current residuals.
+type builder struct {
+	X     [][]float64
+	y     []float64
+	resid []float64
+	p     Params
+	gain  []float64
+	nodes []Node
+}
+
+type split struct {
+	feature   int
+	threshold float64
+	gain      float64
+	left      []int
+	right     []int
+}
+
+// grow adds the subtree for rows idx and returns its node index.
+func (b *builder) grow(idx []int, depth int) int {
+	at := len(b.nodes)
+	b.nodes = append(b.nodes, Node{Left: -1, Right: -1})
+
+	if depth < b.p.MaxDepth && len(idx) >= 2*b.p.MinSamplesLeaf {
+		if s, ok := b.bestSplit(idx); ok {
+			b.gain[s.feature] += s.gain
+			left := b.grow(s.left, depth+1)
+			right := b.grow(s.right, depth+1)
+			b.nodes[at] = Node{Feature: s.feature, Threshold: s.threshold, Left: left, Right: right}
+			return at
+		}
+	}
+
+	b.nodes[at].Value = b.p.LearningRate * b.leafValue(idx)
+	return at
+}
+
+// leafValue is the loss-minimising constant for the leaf: the mean residual for squared
+// error, the residual quantile for pinball loss.
+func (b *builder) leafValue(idx []int) float64 {
+	if b.p.Quantile > 0 {
+		r := make([]float64, len(idx))
+		for k, i := range idx {
+			r[k] = b.resid[i]
+		}
+		return quantile(r, b.p.Quantile)
+	}
+	var sum float64
+	for _, i := range idx {
+		sum += b.resid[i]
+	}
+	return sum / float64(len(idx))
+}
+
+// bestSplit searches every feature and threshold for the largest reduction in squared error.
+// Thresholds are midpoints between consecutive distinct values.
+func (b *builder) bestSplit(idx []int) (split, bool) {
+	var total float64
+	for _, i := range idx {
+		total += b.y[i]
+	}
+	n := float64(len(idx))
+	parent := total * total / n
+
+	best := split{gain: 1e-12}
+	found := false
+	sorted := make([]int, len(idx))
+
+	for f := range b.X[idx[0]] {
+		copy(sorted, idx)
+		sort.SliceStable(sorted, func(a, c int) bool { return b.X[sorted[a]][f] < b.X[sorted[c]][f] })
+
+		var leftSum float64
+		for k := 0; k < len(sorted)-1; k++ {
+			leftSum += b.y[sorted[k]]
+			nl := k + 1
+			nr := len(sorted) - nl
+			if nl < b.p.MinSamplesLeaf || nr < b.p.MinSamplesLeaf {
+				continue
+			}
+			lo, hi := b.X[sorted[k]][f], b.X[sorted[k+1]][f]
+			if lo == hi {
+				continue
+			}
+			rightSum := total - leftSum
+			g := leftSum*leftSum/float64(nl) + rightSum*rightSum/float64(nr) - parent
+			if g > best.gain {
+				best = split{feature: f, threshold: (lo + hi) / 2, gain: g}
+				best.left = append([]int(nil), sorted[:nl]...)
+				best.right = append([]int(nil), sorted[nl:]...)
+				found = true
+			}
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,38 @@
+// Package geo has the small amount of spherical geometry the services need: great-circle
+// distances and offsets on a spherical Earth, which is accurate to well under a percent at
+// town scale.
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius.
+const EarthRadiusKm = 6371.0
+
+// Point is a WGS84 coordinate in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// DistanceKm is the great-circle (haversine) distance between a and b.
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Offset returns the point km away from p in the direction bearing (radians clockwise
+// from north), using a flat approximation that holds for a few tens of km.
+func Offset(p Point, km, bearing float64) Point {
+	return Point{
+		Lat: p.Lat + km/KmPerDegree*math.Cos(bearing),
+		Lon: p.Lon + km/(KmPerDegree*math.Cos(radians(p.Lat)))*math.Sin(bearing),
+	}
+}
+
+// KmPerDegree is the length of one degree of latitude (and of longitude at the equator).
+const KmPerDegree = EarthRadiusKm * math.Pi / 180
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
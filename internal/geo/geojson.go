@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// GeoJSON (RFC 7946) output. Positions are [lon, lat].
+
+// FeatureCollection is a GeoJSON FeatureCollection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a Point ([lon, lat]) or Polygon ([][][lon, lat]) geometry.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewCollection returns an empty FeatureCollection.
+func NewCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+// AddPoint appends a Point feature.
+func (fc *FeatureCollection) AddPoint(p Point, props map[string]interface{}) {
+	fc.add(Geometry{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}}, props)
+}
+
+// AddPolygon appends a Polygon feature with one exterior ring of [lon, lat] pairs. The
+// ring is closed if its first and last points differ.
+func (fc *FeatureCollection) AddPolygon(ring [][2]float64, props map[string]interface{}) {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(ring[:len(ring):len(ring)], ring[0])
+	}
+	fc.add(Geometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}, props)
+}
+
+func (fc *FeatureCollection) add(g Geometry, props map[string]interface{}) {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	fc.Features = append(fc.Features, Feature{Type: "Feature", Geometry: g, Properties: props})
+}
+
+// Write sends fc as application/geo+json.
+func (fc *FeatureCollection) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(fc)
+}
+
+// WantGeoJSON reports whether the request asked for ?format=geojson. Any format other than
+// json or geojson is an error.
+func WantGeoJSON(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		return false, nil
+	case "geojson":
+		return true, nil
+	}
+	return false, errors.New("format must be json or geojson")
+}
+
+// Ring converts points to a [lon, lat] ring.
+func Ring(points []Point) [][2]float64 {
+	out := make([][2]float64, len(points))
+	for i, p := range points {
+		out[i] = [2]float64{p.Lon, p.Lat}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the convex hull of points counter-clockwise, without repeating the
+// first point (Andrew's monotone chain). Fewer than three distinct points come back as-is.
+func ConvexHull(points []Point) []Point {
+	ps := append([]Point(nil), points...)
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Lon != ps[j].Lon {
+			return ps[i].Lon < ps[j].Lon
+		}
+		return ps[i].Lat < ps[j].Lat
+	})
+	uniq := ps[:0]
+	for i, p := range ps {
+		if i == 0 || p != ps[i-1] {
+			uniq = append(uniq, p)
+		}
+	}
+	ps = uniq
+	if len(ps) < 3 {
+		return ps
+	}
+
+	cross := func(o, a, b Point) float64 {
+		return (a.Lon-o.Lon)*(b.Lat-o.Lat) - (a.Lat-o.Lat)*(b.Lon-o.Lon)
+	}
+	hull := make([]Point, 0, 2*len(ps))
+	for _, p := range ps {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, lower := len(ps)-2, len(hull)+1; i >= 0; i-- {
+		p := ps[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// BufferedHull is the convex hull of a small circle (radiusKm, approximated by an octagon)
+// around each point, so one or two points still give an area.
+func BufferedHull(points []Point, radiusKm float64) []Point {
+	var ring []Point
+	for _, p := range points {
+		for k := 0; k < 8; k++ {
+			ring = append(ring, Offset(p, radiusKm, float64(k)*math.Pi/4))
+		}
+	}
+	return ConvexHull(ring)
+}
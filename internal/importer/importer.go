@@ -0,0 +1,304 @@
+// Package importer loads CSV or NDJSON uploads into a service's tables.
+//
+// A service describes its rows with a Spec: how to turn one parsed Row into a typed value
+// (recording field errors on the row) and how to insert a batch. Handler then serves
+// POST /import: every row is validated first, and only a fully valid file is inserted, in
+// one transaction. With ?dry_run=true the inserts run and are rolled back, so constraint
+// violations still surface without changing anything.
+package importer
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxRows caps how many rows one upload may contain.
+	MaxRows = 10000
+	// MaxBytes caps the upload size.
+	MaxBytes = 10 << 20
+)
+
+// Format is an upload's encoding.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// RowError is a problem with one field of one row. Row is the 1-based line number in the
+// upload (for CSV the header is line 1); Field is empty for whole-row problems.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result is the response body of an import.
+type Result struct {
+	Format   Format     `json:"format"`
+	Rows     int        `json:"rows"`
+	Inserted int        `json:"inserted"`
+	DryRun   bool       `json:"dry_run"`
+	Errors   []RowError `json:"errors,omitempty"`
+}
+
+// Row is one parsed record with its fields as strings, keyed by lower-case column name.
+type Row struct {
+	Line   int
+	Fields map[string]string
+	errs   []RowError
+}
+
+// Errorf records a problem with field on this row.
+func (r *Row) Errorf(field, format string, args ...interface{}) {
+	r.errs = append(r.errs, RowError{Row: r.Line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Errors returns the problems recorded on this row.
+func (r *Row) Errors() []RowError { return r.errs }
+
+// String returns a trimmed field, recording an error if it is required and empty.
+func (r *Row) String(field string, required bool) string {
+	v := strings.TrimSpace(r.Fields[field])
+	if v == "" && required {
+		r.Errorf(field, "required")
+	}
+	return v
+}
+
+// Float parses a number field, recording an error if it is missing or malformed.
+func (r *Row) Float(field string) float64 {
+	v := r.String(field, true)
+	if v == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		r.Errorf(field, "not a number: %q", v)
+	}
+	return f
+}
+
+// OptionalFloat is Float with def for an empty field.
+func (r *Row) OptionalFloat(field string, def float64) float64 {
+	if strings.TrimSpace(r.Fields[field]) == "" {
+		return def
+	}
+	return r.Float(field)
+}
+
+// Int parses an integer field, recording an error if it is missing or malformed.
+func (r *Row) Int(field string) int {
+	v := r.String(field, true)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		r.Errorf(field, "not an integer: %q", v)
+	}
+	return n
+}
+
+// Spec describes how to import one table.
+type Spec[T any] struct {
+	// Parse reads and validates one row, recording problems with Row.Errorf.
+	Parse func(*Row) T
+	// Insert writes every parsed value inside tx.
+	Insert func(tx *sql.Tx, values []T) error
+	// AfterCommit, if set, runs after a real (not dry-run) import commits.
+	AfterCommit func() error
+}
+
+// Handler serves POST /import for spec. The format comes from ?format= or the
+// Content-Type (text/csv, application/x-ndjson); dry_run=true rolls back instead of
+// committing. Responds 201 on import, 200 on a successful dry run, and 422 with per-row
+// errors if any row is invalid.
+func Handler[T any](db *sql.DB, spec Spec[T]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		format, err := detectFormat(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+		rows, err := Read(http.MaxBytesReader(w, r.Body, MaxBytes), format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := Result{Format: format, Rows: len(rows), DryRun: dryRun}
+		values := make([]T, 0, len(rows))
+		for _, row := range rows {
+			v := spec.Parse(row)
+			res.Errors = append(res.Errors, row.Errors()...)
+			values = append(values, v)
+		}
+		if len(res.Errors) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(res)
+			return
+		}
+
+		if err := load(db, spec, values, dryRun); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Inserted = len(values)
+		if dryRun {
+			res.Inserted = 0
+		} else if spec.AfterCommit != nil {
+			if err := spec.AfterCommit(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if !dryRun {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+func load[T any](db *sql.DB, spec Spec[T], values []T, dryRun bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := spec.Insert(tx, values); err != nil {
+		return err
+	}
+	if dryRun {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+func detectFormat(r *http.Request) (Format, error) {
+	f := strings.ToLower(r.URL.Query().Get("format"))
+	if f == "" {
+		mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mt {
+		case "text/csv":
+			f = string(CSV)
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			f = string(NDJSON)
+		}
+	}
+	switch Format(f) {
+	case CSV, NDJSON:
+		return Format(f), nil
+	}
+	return "", errors.New("format must be csv or ndjson (?format= or Content-Type text/csv / application/x-ndjson)")
+}
+
+// Read parses an upload into rows. It fails on malformed input or more than MaxRows rows;
+// field-level problems are left to the Spec.
+func Read(r io.Reader, format Format) ([]*Row, error) {
+	switch format {
+	case CSV:
+		return readCSV(r)
+	case NDJSON:
+		return readNDJSON(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func readCSV(r io.Reader) ([]*Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	var rows []*Row
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("more than %d rows", MaxRows)
+		}
+		line, _ := cr.FieldPos(0)
+		row := &Row{Line: line, Fields: map[string]string{}}
+		for i, v := range rec {
+			row.Fields[header[i]] = v
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no data rows")
+	}
+	return rows, nil
+}
+
+func readNDJSON(r io.Reader) ([]*Row, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var rows []*Row
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("more than %d rows", MaxRows)
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		row := &Row{Line: line, Fields: map[string]string{}}
+		for k, v := range obj {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				row.Fields[strings.ToLower(k)] = v
+			case float64:
+				row.Fields[strings.ToLower(k)] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				row.Fields[strings.ToLower(k)] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no data rows")
+	}
+	return rows, nil
+}
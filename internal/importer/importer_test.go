@@ -0,0 +1,182 @@
+package importer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// item is a minimal row type for exercising the Row helpers the services' Specs use.
+type item struct {
+	Name  string
+	Price float64
+	Qty   int
+	Unit  string
+}
+
+func parseItem(r *Row) item {
+	it := item{
+		Name:  r.String("name", true),
+		Price: r.Float("price"),
+		Qty:   r.Int("qty"),
+		Unit:  r.String("unit", false),
+	}
+	if it.Price < 0 {
+		r.Errorf("price", "must not be negative")
+	}
+	return it
+}
+
+var itemSpec = Spec[item]{Parse: parseItem}
+
+// parseAll reads body and parses every row, returning the values and all row errors.
+func parseAll(t *testing.T, body string, format Format) ([]item, []RowError) {
+	t.Helper()
+	rows, err := Read(strings.NewReader(body), format)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	var items []item
+	var errs []RowError
+	for _, row := range rows {
+		items = append(items, parseItem(row))
+		errs = append(errs, row.Errors()...)
+	}
+	return items, errs
+}
+
+func TestRowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		body   string
+		want   []RowError
+	}{
+		{
+			name:   "csv valid",
+			format: CSV,
+			body:   "name,price,qty,unit\nRice,52.5,2,kg\nMilk,30,1,\n",
+		},
+		{
+			name:   "csv lines count the header",
+			format: CSV,
+			body:   "name,price,qty\nRice,52.5,2\n,abc,1.5\nDal,-3,1\n",
+			want: []RowError{
+				{Row: 3, Field: "name", Message: "required"},
+				{Row: 3, Field: "price", Message: `not a number: "abc"`},
+				{Row: 3, Field: "qty", Message: `not an integer: "1.5"`},
+				{Row: 4, Field: "price", Message: "must not be negative"},
+			},
+		},
+		{
+			name:   "csv header case, BOM and spacing",
+			format: CSV,
+			body:   "\ufeffName, PRICE ,Qty\nRice, 52.5,2\n",
+		},
+		{
+			name:   "csv quoted newline keeps the row's first line",
+			format: CSV,
+			body:   "name,price,qty\n\"Basmati\nrice\",90,1\nOil,,1\n",
+			want:   []RowError{{Row: 4, Field: "price", Message: "required"}},
+		},
+		{
+			name:   "ndjson valid",
+			format: NDJSON,
+			body:   `{"name":"Rice","price":52.5,"qty":2}` + "\n" + `{"Name":"Milk","Price":"30","QTY":1}` + "\n",
+		},
+		{
+			name:   "ndjson blank lines keep numbering",
+			format: NDJSON,
+			body:   `{"name":"Rice","price":52.5,"qty":2}` + "\n\n" + `{"name":"","price":null,"qty":true}` + "\n",
+			want: []RowError{
+				{Row: 3, Field: "name", Message: "required"},
+				{Row: 3, Field: "price", Message: "required"},
+				{Row: 3, Field: "qty", Message: `not an integer: "true"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := parseAll(t, tt.body, tt.format)
+			if !reflect.DeepEqual(errs, tt.want) {
+				t.Errorf("row errors = %+v, want %+v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadValues(t *testing.T) {
+	csvItems, _ := parseAll(t, "name,price,qty,unit\nRice,52.5,2,kg\n", CSV)
+	jsonItems, _ := parseAll(t, `{"name":"Rice","price":52.5,"qty":2,"unit":"kg"}`, NDJSON)
+	want := []item{{Name: "Rice", Price: 52.5, Qty: 2, Unit: "kg"}}
+	if !reflect.DeepEqual(csvItems, want) || !reflect.DeepEqual(jsonItems, want) {
+		t.Errorf("csv %+v, ndjson %+v, want %+v", csvItems, jsonItems, want)
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		body   string
+	}{
+		{"csv empty", CSV, ""},
+		{"csv header only", CSV, "name,price,qty\n"},
+		{"csv ragged row", CSV, "name,price,qty\nRice,52.5\n"},
+		{"ndjson empty", NDJSON, "\n\n"},
+		{"ndjson malformed line", NDJSON, `{"name":"Rice"}` + "\n" + `{"name":` + "\n"},
+		{"ndjson array", NDJSON, `[1,2]`},
+		{"csv too many rows", CSV, "name\n" + strings.Repeat("x\n", MaxRows+1)},
+		{"unknown format", Format("xml"), "<a/>"},
+	}
+	for _, tt := range tests {
+		if _, err := Read(strings.NewReader(tt.body), tt.format); err == nil {
+			t.Errorf("%s: Read() succeeded, want an error", tt.name)
+		}
+	}
+}
+
+// TestHandlerRejectsInvalidFile checks that a file with any bad row answers 422 with every
+// row error and never reaches the database (db is nil here).
+func TestHandlerRejectsInvalidFile(t *testing.T) {
+	h := Handler[item](nil, itemSpec)
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		body        string
+		status      int
+		errors      int
+	}{
+		{"csv by content type", "text/csv", "", "name,price,qty\nRice,x,1\n,1,1\n", http.StatusUnprocessableEntity, 2},
+		{"ndjson by query", "", "?format=ndjson", `{"name":"Rice","price":1}`, http.StatusUnprocessableEntity, 1},
+		{"unknown format", "application/xml", "", "<a/>", http.StatusUnsupportedMediaType, 0},
+		{"malformed ndjson", "application/x-ndjson", "", "{", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/import"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusUnprocessableEntity {
+				return
+			}
+			var res Result
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Errors) != tt.errors || res.Inserted != 0 {
+				t.Errorf("result = %+v, want %d errors and nothing inserted", res, tt.errors)
+			}
+		})
+	}
+}
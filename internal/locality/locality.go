@@ -0,0 +1,198 @@
+// Package locality is the registry of Ashta's localities: the localities table, lookups by
+// name or slug, and validation of new entries. Listings and routes reference localities by
+// name, so services resolve user input here and reject names the registry doesn't know.
+package locality
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+// Bounding box around Ashta; centroids, boundaries and listings must fall inside it.
+const (
+	MinLat = 22.95
+	MaxLat = 23.10
+	MinLon = 76.65
+	MaxLon = 76.80
+)
+
+// ErrUnknown is returned for a name or slug that isn't registered.
+var ErrUnknown = errors.New("unknown locality")
+
+// Querier is satisfied by *sql.DB and *sql.Tx.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+const selectColumns = `SELECT id, name, slug, lat, lon, boundary, COALESCE(population, 0) FROM localities`
+
+// List returns every registered locality, ordered by name.
+func List(q Querier) ([]models.Locality, error) {
+	rows, err := q.Query(selectColumns + ` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Locality{}
+	for rows.Next() {
+		l, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// Get finds a locality by name (case-insensitive) or slug, returning ErrUnknown if there
+// is none.
+func Get(q Querier, ref string) (models.Locality, error) {
+	ref = strings.TrimSpace(ref)
+	l, err := scan(q.QueryRow(selectColumns+` WHERE LOWER(name) = LOWER($1) OR slug = LOWER($1)`, ref))
+	if err == sql.ErrNoRows {
+		return l, fmt.Errorf("%w %q", ErrUnknown, ref)
+	}
+	return l, err
+}
+
+// Insert registers l and returns it with its id. Slug is derived from Name if empty.
+func Insert(q Querier, l models.Locality) (models.Locality, error) {
+	if l.Slug == "" {
+		l.Slug = Slug(l.Name)
+	}
+	var boundary interface{}
+	if len(l.Boundary) > 0 {
+		b, _ := json.Marshal(l.Boundary)
+		boundary = string(b)
+	}
+	var population interface{}
+	if l.Population > 0 {
+		population = l.Population
+	}
+	err := q.QueryRow(`
+		INSERT INTO localities (name, slug, lat, lon, boundary, population)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, l.Name, l.Slug, l.Lat, l.Lon, boundary, population).Scan(&l.ID)
+	return l, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(s scanner) (models.Locality, error) {
+	var l models.Locality
+	var boundary []byte
+	if err := s.Scan(&l.ID, &l.Name, &l.Slug, &l.Lat, &l.Lon, &boundary, &l.Population); err != nil {
+		return l, err
+	}
+	if len(boundary) > 0 {
+		if err := json.Unmarshal(boundary, &l.Boundary); err != nil {
+			return l, fmt.Errorf("locality %s: boundary: %w", l.Name, err)
+		}
+	}
+	return l, nil
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug turns a name into its URL form: "Ashta Central" -> "ashta-central".
+func Slug(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// Validate checks a locality before it is registered and returns a field -> message map
+// (empty when valid).
+func Validate(l models.Locality) map[string]string {
+	errs := map[string]string{}
+	if name := strings.TrimSpace(l.Name); name == "" || len(name) > 100 {
+		errs["name"] = "required, at most 100 characters"
+	}
+	if l.Slug != "" && (l.Slug != Slug(l.Slug) || len(l.Slug) > 100) {
+		errs["slug"] = "lower-case letters, digits and hyphens only"
+	}
+	if !InBounds(l.Lat, l.Lon) {
+		errs["lat"] = "centroid must be within the Ashta bounding box"
+	}
+	if len(l.Boundary) > 0 {
+		ring := l.Boundary
+		switch {
+		case len(ring) < 4:
+			errs["boundary"] = "needs at least 4 points"
+		case ring[0] != ring[len(ring)-1]:
+			errs["boundary"] = "first and last points must be equal"
+		default:
+			for _, p := range ring {
+				if !InBounds(p[1], p[0]) {
+					errs["boundary"] = "points are [lon, lat] and must be within the Ashta bounding box"
+					break
+				}
+			}
+		}
+	}
+	if l.Population < 0 {
+		errs["population"] = "must not be negative"
+	}
+	return errs
+}
+
+// InBounds reports whether a point is inside the Ashta bounding box.
+func InBounds(lat, lon float64) bool {
+	return lat >= MinLat && lat <= MaxLat && lon >= MinLon && lon <= MaxLon
+}
+
+// Registry caches the locality list for request paths that resolve many names, such as
+// imports. A miss reloads the list once, so localities registered since the last load are
+// found.
+type Registry struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	byRef map[string]models.Locality
+}
+
+// NewRegistry returns a Registry reading from db. Nothing is loaded until first use.
+func NewRegistry(db *sql.DB) *Registry {
+	return &Registry{db: db}
+}
+
+// Resolve finds a locality by name (case-insensitive) or slug, returning ErrUnknown if
+// there is none.
+func (r *Registry) Resolve(ref string) (models.Locality, error) {
+	key := strings.ToLower(strings.TrimSpace(ref))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.byRef[key]; ok {
+		return l, nil
+	}
+	if err := r.load(); err != nil {
+		return models.Locality{}, err
+	}
+	if l, ok := r.byRef[key]; ok {
+		return l, nil
+	}
+	return models.Locality{}, fmt.Errorf("%w %q", ErrUnknown, strings.TrimSpace(ref))
+}
+
+func (r *Registry) load() error {
+	list, err := List(r.db)
+	if err != nil {
+		return err
+	}
+	r.byRef = make(map[string]models.Locality, 2*len(list))
+	for _, l := range list {
+		r.byRef[strings.ToLower(l.Name)] = l
+		r.byRef[l.Slug] = l
+	}
+	return nil
+}
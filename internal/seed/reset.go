@@ -0,0 +1,95 @@
+package seed
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Reset describes how a service rebuilds its mock data.
+type Reset struct {
+	// Tables are truncated (restarting their id sequences) before Fill runs.
+	Tables []string
+	// Fill inserts the generated rows inside tx and returns how many it wrote.
+	Fill func(tx *sql.Tx, c Config) (int, error)
+	// AfterCommit, if set, runs once the new data is committed, e.g. to reclassify.
+	AfterCommit func(c Config) error
+}
+
+// ResetResult is the response body of a reset.
+type ResetResult struct {
+	Seed            int64    `json:"seed"`
+	Listings        int      `json:"listings"`
+	InflationMonths int      `json:"inflation_months"`
+	Localities      []string `json:"localities"`
+	Tables          []string `json:"tables"`
+	Rows            int      `json:"rows"`
+}
+
+// Run empties reset's tables and refills them from c in one transaction.
+func Run(db *sql.DB, reset Reset, c Config) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("TRUNCATE " + strings.Join(reset.Tables, ", ") + " RESTART IDENTITY CASCADE"); err != nil {
+		return 0, err
+	}
+	n, err := reset.Fill(tx, c)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if reset.AfterCommit != nil {
+		if err := reset.AfterCommit(c); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// IfEmpty fills reset's tables from c when the first of them has no rows, so services
+// seed a fresh database on startup and leave existing data alone.
+func IfEmpty(db *sql.DB, reset Reset, c Config) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + reset.Tables[0]).Scan(&count); err != nil || count > 0 {
+		return 0, err
+	}
+	return Run(db, reset, c)
+}
+
+// Handler serves POST /admin/reset-and-seed: it wipes the service's tables and reloads the
+// dataset for base, overridden by ?seed=, ?listings=, ?inflation_months= and ?localities=.
+// The same parameters always produce the same data.
+func Handler(db *sql.DB, base Config, reset Reset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		c, err := base.WithQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := Run(db, reset, c)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(ResetResult{
+			Seed:            c.Seed,
+			Listings:        c.Listings,
+			InflationMonths: c.InflationMonths,
+			Localities:      c.Localities,
+			Tables:          reset.Tables,
+			Rows:            n,
+		})
+	}
+}
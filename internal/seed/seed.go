@@ -0,0 +1,293 @@
+// Package seed generates the mock dataset the services load into an empty database.
+//
+// Generation is deterministic: the same Config always yields the same rows, so demos and
+// integration tests can rely on a known dataset. Each dataset draws from its own random
+// source derived from Config.Seed, so changing the listing count doesn't reshuffle routes.
+package seed
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"rent-cost-analyzer/internal/fare"
+	"rent-cost-analyzer/internal/geo"
+	"rent-cost-analyzer/internal/locality"
+	"rent-cost-analyzer/pkg/models"
+)
+
+// Defaults used when neither env nor flags say otherwise.
+const (
+	DefaultSeed            = 42
+	DefaultListings        = 20
+	DefaultInflationMonths = 6
+	maxListings            = 10000
+	maxInflationMonths     = 120
+)
+
+// centre is Ashta Central's centroid; listing distances are measured from it and
+// unregistered localities are placed around it.
+var centre = geo.Point{Lat: 23.0198, Lon: 76.7224}
+
+// inflationEnd is the newest month of generated inflation data.
+var inflationEnd = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Config controls what is generated.
+type Config struct {
+	Seed            int64
+	Listings        int
+	InflationMonths int
+	// Localities are names or slugs; empty means every registered locality. Names the
+	// registry doesn't know are placed deterministically around the town centre and
+	// registered when the data is loaded.
+	Localities []string
+}
+
+// DefaultConfig returns the built-in dataset settings.
+func DefaultConfig() Config {
+	return Config{
+		Seed:            DefaultSeed,
+		Listings:        DefaultListings,
+		InflationMonths: DefaultInflationMonths,
+	}
+}
+
+// FromEnv returns DefaultConfig overridden by SEED, SEED_LISTINGS, SEED_INFLATION_MONTHS
+// and SEED_LOCALITIES (comma-separated). Malformed values are reported and ignored.
+func FromEnv() Config {
+	c := DefaultConfig()
+	if err := c.apply(map[string]string{
+		"seed":             os.Getenv("SEED"),
+		"listings":         os.Getenv("SEED_LISTINGS"),
+		"inflation_months": os.Getenv("SEED_INFLATION_MONTHS"),
+		"localities":       os.Getenv("SEED_LOCALITIES"),
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "seed: ignoring env:", err)
+		return DefaultConfig()
+	}
+	return c
+}
+
+// RegisterFlags adds -seed, -seed-listings, -seed-inflation-months and -seed-localities to
+// fs, defaulting to c's current values.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "mock data random seed")
+	fs.IntVar(&c.Listings, "seed-listings", c.Listings, "number of mock rental listings")
+	fs.IntVar(&c.InflationMonths, "seed-inflation-months", c.InflationMonths, "months of mock inflation data")
+	fs.Func("seed-localities", "comma-separated mock localities", func(s string) error {
+		c.Localities = splitNames(s)
+		return nil
+	})
+}
+
+// WithQuery returns a copy of c overridden by the query parameters seed, listings,
+// inflation_months and localities.
+func (c Config) WithQuery(q url.Values) (Config, error) {
+	err := c.apply(map[string]string{
+		"seed":             q.Get("seed"),
+		"listings":         q.Get("listings"),
+		"inflation_months": q.Get("inflation_months"),
+		"localities":       q.Get("localities"),
+	})
+	return c, err
+}
+
+func (c *Config) apply(v map[string]string) error {
+	if s := v["seed"]; s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("seed: %w", err)
+		}
+		c.Seed = n
+	}
+	if s := v["listings"]; s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("listings: %w", err)
+		}
+		c.Listings = n
+	}
+	if s := v["inflation_months"]; s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("inflation_months: %w", err)
+		}
+		c.InflationMonths = n
+	}
+	if s := v["localities"]; s != "" {
+		c.Localities = splitNames(s)
+	}
+	return c.Validate()
+}
+
+// Validate checks the config's ranges.
+func (c Config) Validate() error {
+	if c.Listings < 0 || c.Listings > maxListings {
+		return fmt.Errorf("listings must be 0-%d", maxListings)
+	}
+	if c.InflationMonths < 1 || c.InflationMonths > maxInflationMonths {
+		return fmt.Errorf("inflation_months must be 1-%d", maxInflationMonths)
+	}
+	if len(c.Localities) == 1 {
+		return errors.New("need at least two localities")
+	}
+	return nil
+}
+
+func splitNames(s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, n := range strings.Split(s, ",") {
+		n = strings.TrimSpace(n)
+		if n != "" && !seen[strings.ToLower(n)] {
+			seen[strings.ToLower(n)] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// rng returns the random source for one dataset.
+func (c Config) rng(dataset string) *rand.Rand {
+	h := c.Seed
+	for _, b := range []byte(dataset) {
+		h = h*31 + int64(b)
+	}
+	return rand.New(rand.NewSource(h))
+}
+
+// Localities resolves the configured names against the registry, registering any it
+// doesn't know, and returns them in configured order (registry order when none are set).
+func Localities(q locality.Querier, c Config) ([]models.Locality, error) {
+	registered, err := locality.List(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Localities) == 0 {
+		if len(registered) < 2 {
+			return nil, errors.New("need at least two registered localities")
+		}
+		return registered, nil
+	}
+
+	r := c.rng("localities")
+	out := make([]models.Locality, 0, len(c.Localities))
+	for _, ref := range c.Localities {
+		// Draw for every name so a locality's placement doesn't depend on which others
+		// happen to be registered already.
+		bearing := r.Float64() * 2 * math.Pi
+		km := 1.5 + r.Float64()*4.5
+		if l, ok := findLocality(registered, ref); ok {
+			out = append(out, l)
+			continue
+		}
+		p := geo.Offset(centre, km, bearing)
+		l, err := locality.Insert(q, models.Locality{Name: ref, Lat: round6(p.Lat), Lon: round6(p.Lon)})
+		if err != nil {
+			return nil, fmt.Errorf("register locality %q: %w", ref, err)
+		}
+		registered = append(registered, l)
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func findLocality(list []models.Locality, ref string) (models.Locality, bool) {
+	for _, l := range list {
+		if strings.EqualFold(l.Name, ref) || l.Slug == strings.ToLower(ref) {
+			return l, true
+		}
+	}
+	return models.Locality{}, false
+}
+
+// Listings generates rental listings scattered around their localities' centroids. About
+// 30% carry a markup so the classifier has overpriced listings to find.
+func Listings(c Config, locs []models.Locality) []models.RentalListing {
+	r := c.rng("listings")
+	out := make([]models.RentalListing, 0, c.Listings)
+	for i := 0; i < c.Listings; i++ {
+		loc := locs[r.Intn(len(locs))]
+		bedrooms := r.Intn(3) + 1
+		sqft := 400 + r.Intn(1200)
+		rent := float64(bedrooms)*2500 + float64(sqft)*0.5 + r.Float64()*1000 - 500
+		if r.Float64() > 0.7 {
+			rent *= 1.3
+		}
+		lat := loc.Lat + (r.Float64()-0.5)*0.012
+		lon := loc.Lon + (r.Float64()-0.5)*0.012
+		out = append(out, models.RentalListing{
+			Locality:       loc.Name,
+			Rent:           round2(rent),
+			Bedrooms:       bedrooms,
+			Sqft:           sqft,
+			Classification: "fair",
+			Distance:       round2(geo.DistanceKm(centre, geo.Point{Lat: lat, Lon: lon})),
+			Lat:            round6(lat),
+			Lon:            round6(lon),
+		})
+	}
+	return out
+}
+
+// Routes generates a route in each direction between every pair of localities. Road
+// distance is 1.3× the straight line with ±10% noise; the fare is the BCLL bus slab fare.
+func Routes(c Config, locs []models.Locality) []models.TransportRoute {
+	r := c.rng("routes")
+	var out []models.TransportRoute
+	for i, a := range locs {
+		for _, b := range locs[i+1:] {
+			dist := geo.DistanceKm(geo.Point{Lat: a.Lat, Lon: a.Lon}, geo.Point{Lat: b.Lat, Lon: b.Lon}) * 1.3 * (0.9 + r.Float64()*0.2)
+			dist = math.Max(1, round2(dist))
+			busFare := fare.BusFare(dist)
+			out = append(out,
+				models.TransportRoute{FromLocality: a.Name, ToLocality: b.Name, Distance: dist, Fare: busFare},
+				models.TransportRoute{FromLocality: b.Name, ToLocality: a.Name, Distance: dist, Fare: busFare},
+			)
+		}
+	}
+	return out
+}
+
+// InflationCategories are the series generated for each month.
+var InflationCategories = []string{"Food", "Housing", "Transport", "Overall"}
+
+// Inflation generates monthly rates between 5.5% and 8% for every category, newest month
+// first, ending January 2025.
+func Inflation(c Config) []models.InflationRecord {
+	r := c.rng("inflation")
+	var out []models.InflationRecord
+	for k := 0; k < c.InflationMonths; k++ {
+		period := inflationEnd.AddDate(0, -k, 0)
+		for _, cat := range InflationCategories {
+			out = append(out, models.InflationRecord{
+				Period: period, Month: period.Format("Jan 2006"), Category: cat, Rate: round2(5.5 + r.Float64()*2.5),
+			})
+		}
+	}
+	return out
+}
+
+// Groceries returns the staple basket with listed prices and per-person weekly quantities.
+func Groceries() []models.GroceryItem {
+	return []models.GroceryItem{
+		{Item: "Rice (1kg)", Price: 45.0, Source: "BigBasket", Unit: "kg", AdultWeeklyQty: 1.0, ChildWeeklyQty: 0.5},
+		{Item: "Wheat Flour (1kg)", Price: 40.0, Source: "Blinkit", Unit: "kg", AdultWeeklyQty: 1.2, ChildWeeklyQty: 0.6},
+		{Item: "Cooking Oil (1L)", Price: 150.0, Source: "BigBasket", Unit: "L", AdultWeeklyQty: 0.25, ChildWeeklyQty: 0.1},
+		{Item: "Milk (1L)", Price: 55.0, Source: "Blinkit", Unit: "L", AdultWeeklyQty: 2.5, ChildWeeklyQty: 2.0},
+		{Item: "Vegetables (weekly)", Price: 300.0, Source: "BigBasket", Unit: "basket", AdultWeeklyQty: 0.4, ChildWeeklyQty: 0.25},
+		{Item: "Lentils (1kg)", Price: 80.0, Source: "Blinkit", Unit: "kg", AdultWeeklyQty: 0.3, ChildWeeklyQty: 0.15},
+		{Item: "Sugar (1kg)", Price: 42.0, Source: "BigBasket", Unit: "kg", AdultWeeklyQty: 0.25, ChildWeeklyQty: 0.15},
+		{Item: "Tea/Coffee", Price: 120.0, Source: "Blinkit", Unit: "pack", AdultWeeklyQty: 0.1, ChildWeeklyQty: 0},
+	}
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+func round6(v float64) float64 { return math.Round(v*1e6) / 1e6 }
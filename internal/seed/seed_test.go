@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+
+	"rent-cost-analyzer/pkg/models"
+)
+
+// testLocalities stands in for the registry, so the generators run without a database.
+var testLocalities = []models.Locality{
+	{ID: 1, Name: "Ashta Central", Slug: "ashta-central", Lat: 23.0198, Lon: 76.7224},
+	{ID: 2, Name: "Kannod Road", Slug: "kannod-road", Lat: 23.0312, Lon: 76.7401},
+	{ID: 3, Name: "Sehore Naka", Slug: "sehore-naka", Lat: 23.0075, Lon: 76.7053},
+}
+
+// generated is every dataset the generators produce from one Config.
+type generated struct {
+	listings  []models.RentalListing
+	routes    []models.TransportRoute
+	inflation []models.InflationRecord
+}
+
+func generate(c Config) generated {
+	return generated{
+		listings:  Listings(c, testLocalities),
+		routes:    Routes(c, testLocalities),
+		inflation: Inflation(c),
+	}
+}
+
+func TestGeneratorsDeterministic(t *testing.T) {
+	c := DefaultConfig()
+	c.Listings = 50
+	c.InflationMonths = 12
+
+	first, second := generate(c), generate(c)
+	if len(first.listings) != 50 || len(first.routes) != 6 || len(first.inflation) != 12*len(InflationCategories) {
+		t.Fatalf("got %d listings, %d routes, %d inflation rows; want 50, 6, %d",
+			len(first.listings), len(first.routes), len(first.inflation), 12*len(InflationCategories))
+	}
+	if !reflect.DeepEqual(first.listings, second.listings) {
+		t.Error("same config gave different listings")
+	}
+	if !reflect.DeepEqual(first.routes, second.routes) {
+		t.Error("same config gave different routes")
+	}
+	if !reflect.DeepEqual(first.inflation, second.inflation) {
+		t.Error("same config gave different inflation rates")
+	}
+
+	other := c
+	other.Seed = c.Seed + 1
+	diff := generate(other)
+	if reflect.DeepEqual(first.listings, diff.listings) {
+		t.Error("a different seed gave the same listings")
+	}
+	if reflect.DeepEqual(first.routes, diff.routes) {
+		t.Error("a different seed gave the same routes")
+	}
+	if reflect.DeepEqual(first.inflation, diff.inflation) {
+		t.Error("a different seed gave the same inflation rates")
+	}
+}
+
+// TestGeneratorsIndependent checks that each dataset has its own random source: changing
+// how many listings or months are generated leaves the other datasets alone and keeps the
+// rows they share.
+func TestGeneratorsIndependent(t *testing.T) {
+	c := DefaultConfig()
+	more := c
+	more.Listings = c.Listings * 3
+	more.InflationMonths = c.InflationMonths * 2
+
+	base, bigger := generate(c), generate(more)
+	if !reflect.DeepEqual(base.routes, bigger.routes) {
+		t.Error("changing the listing count reshuffled routes")
+	}
+	if !reflect.DeepEqual(base.listings, bigger.listings[:len(base.listings)]) {
+		t.Error("more listings changed the first ones")
+	}
+	if !reflect.DeepEqual(base.inflation, bigger.inflation[:len(base.inflation)]) {
+		t.Error("more months changed the newest ones")
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // UserProfile holds user preferences and context for cost analysis.
 type UserProfile struct {
 	ID                int     `json:"id,omitempty"`
@@ -8,6 +10,13 @@ type UserProfile struct {
 	FamilySize        int     `json:"family_size"`
 	PreferredLocale   string  `json:"preferred_locale"`
 	CommuteDistance   float64 `json:"commute_distance"`
+	// Workplace is the locality the user commutes to.
+	Workplace         string  `json:"workplace,omitempty"`
+	// CanWrite grants the profile's tokens the write scope on shared data; only an admin
+	// sets it.
+	CanWrite          bool    `json:"can_write"`
+	// Password is accepted on create/update only; it is never returned.
+	Password          string  `json:"password,omitempty"`
 }
 
 // RentalListing represents a single rental listing.
@@ -18,11 +27,27 @@ type RentalListing struct {
 	Bedrooms       int     `json:"bedrooms"`
 	Sqft           int     `json:"sqft"`
 	Classification string  `json:"classification"`
+	ExpectedRent   float64 `json:"expected_rent"`
+	ZScore         float64 `json:"z_score"`
 	Distance       float64 `json:"distance"`
 	Lat            float64 `json:"lat,omitempty"`
 	Lon            float64 `json:"lon,omitempty"`
 }
 
+// ListingValuation explains a listing's classification: the rent expected from its
+// comparables and how far the asking rent deviates from it.
+type ListingValuation struct {
+	ListingID      int             `json:"listing_id"`
+	Rent           float64         `json:"rent"`
+	ExpectedRent   float64         `json:"expected_rent"`
+	ZScore         float64         `json:"z_score"`
+	Classification string          `json:"classification"`
+	Basis          string          `json:"basis"`
+	MeanPerSqft    float64         `json:"mean_rent_per_sqft"`
+	StdDevPerSqft  float64         `json:"stddev_rent_per_sqft"`
+	Comparables    []RentalListing `json:"comparables"`
+}
+
 // CostAnalysis holds aggregated cost metrics for a locality.
 type CostAnalysis struct {
 	Rent          float64 `json:"rent"`
@@ -33,11 +58,145 @@ type CostAnalysis struct {
 	InflationRate float64 `json:"inflation_rate,omitempty"`
 }
 
-// GroceryItem represents a grocery item with price and source.
+// GroceryItem represents a grocery item with price and source. Price is per Unit; the
+// weekly quantities are how many units one adult or child consumes.
 type GroceryItem struct {
-	Item   string  `json:"item"`
-	Price  float64 `json:"price"`
-	Source string  `json:"source"`
+	ID             int     `json:"id,omitempty"`
+	Item           string  `json:"item"`
+	Price          float64 `json:"price"`
+	Source         string  `json:"source"`
+	Unit           string  `json:"unit"`
+	AdultWeeklyQty float64 `json:"adult_weekly_qty"`
+	ChildWeeklyQty float64 `json:"child_weekly_qty"`
+}
+
+// BasketLine is one item's share of a household grocery basket.
+type BasketLine struct {
+	Item        string  `json:"item"`
+	Unit        string  `json:"unit"`
+	Price       float64 `json:"price"`
+	Source      string  `json:"source"`
+	WeeklyQty   float64 `json:"weekly_qty"`
+	MonthlyQty  float64 `json:"monthly_qty"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// GroceryBasket is a household's monthly grocery cost with a per-item breakdown.
+type GroceryBasket struct {
+	UserID       int          `json:"user_id,omitempty"`
+	Adults       int          `json:"adults"`
+	Children     int          `json:"children"`
+	Items        []BasketLine `json:"items"`
+	WeeklyTotal  float64      `json:"weekly_total"`
+	MonthlyTotal float64      `json:"monthly_total"`
+}
+
+// PriceQuote is one source's latest price for an item.
+type PriceQuote struct {
+	Source     string    `json:"source"`
+	Price      float64   `json:"price"`
+	ObservedAt time.Time `json:"observed_at"`
+	// Synthetic is set on quotes generated by the seed rather than observed.
+	Synthetic bool `json:"synthetic,omitempty"`
+}
+
+// ItemPrices compares every source's price for one basket item.
+type ItemPrices struct {
+	Item           string       `json:"item"`
+	Unit           string       `json:"unit"`
+	MonthlyQty     float64      `json:"monthly_qty"`
+	Quotes         []PriceQuote `json:"quotes"`
+	CheapestSource string       `json:"cheapest_source"`
+	CheapestPrice  float64      `json:"cheapest_price"`
+}
+
+// VendorBasket is the monthly basket bought entirely from one source. A vendor that does
+// not stock every item is incomplete and lists what is missing.
+type VendorBasket struct {
+	Source       string   `json:"source"`
+	MonthlyTotal float64  `json:"monthly_total"`
+	Complete     bool     `json:"complete"`
+	Missing      []string `json:"missing,omitempty"`
+}
+
+// SplitBasket buys every item from its cheapest source.
+type SplitBasket struct {
+	MonthlyTotal float64            `json:"monthly_total"`
+	BySource     map[string]float64 `json:"by_source"`
+}
+
+// GrocerySavings is how much a month's basket drops from the listed prices.
+type GrocerySavings struct {
+	SingleVendor float64 `json:"single_vendor"`
+	SplitVendor  float64 `json:"split_vendor"`
+}
+
+// PriceComparison compares a household basket across grocery sources.
+type PriceComparison struct {
+	UserID         int            `json:"user_id,omitempty"`
+	Adults         int            `json:"adults"`
+	Children       int            `json:"children"`
+	Items          []ItemPrices   `json:"items"`
+	CurrentTotal   float64        `json:"current_total"`
+	SingleVendor   []VendorBasket `json:"single_vendor"`
+	CheapestVendor string         `json:"cheapest_vendor,omitempty"`
+	SplitVendor    SplitBasket    `json:"split_vendor"`
+	Savings        GrocerySavings `json:"savings"`
+}
+
+// PriceObservation is one dated price of an item at a source.
+type PriceObservation struct {
+	ItemID     int       `json:"item_id"`
+	Item       string    `json:"item"`
+	Source     string    `json:"source"`
+	Price      float64   `json:"price"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// PricePoint is one month of an item's price history. MoMChangePct is nil when the
+// previous calendar month has no observations; Synthetic counts the month's observations
+// that were generated by the seed.
+type PricePoint struct {
+	Month        string   `json:"month"` // YYYY-MM
+	AvgPrice     float64  `json:"avg_price"`
+	MinPrice     float64  `json:"min_price"`
+	MaxPrice     float64  `json:"max_price"`
+	Observations int      `json:"observations"`
+	Synthetic    int      `json:"synthetic"`
+	MoMChangePct *float64 `json:"mom_change_pct"`
+}
+
+// PriceHistory is an item's monthly price series, optionally for a single source.
+type PriceHistory struct {
+	ItemID int          `json:"item_id"`
+	Item   string       `json:"item"`
+	Unit   string       `json:"unit"`
+	Source string       `json:"source,omitempty"`
+	Points []PricePoint `json:"points"`
+}
+
+// SplitHousehold divides a family size into adults and children: the first two members
+// are adults, the rest children. Sizes below 1 count as one adult.
+func SplitHousehold(familySize int) (adults, children int) {
+	if familySize < 1 {
+		return 1, 0
+	}
+	if familySize <= 2 {
+		return familySize, 0
+	}
+	return 2, familySize - 2
+}
+
+// Locality is a registered neighbourhood. Boundary is a closed ring of [lon, lat] pairs
+// (GeoJSON order); Population is 0 when unknown.
+type Locality struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Slug       string       `json:"slug"`
+	Lat        float64      `json:"lat"`
+	Lon        float64      `json:"lon"`
+	Boundary   [][2]float64 `json:"boundary,omitempty"`
+	Population int          `json:"population,omitempty"`
 }
 
 // TransportRoute represents a route between two localities.
@@ -49,10 +208,12 @@ type TransportRoute struct {
 	Fare         float64 `json:"fare"`
 }
 
-// InflationRecord represents inflation rate for a month/category.
+// InflationRecord represents inflation rate for a month/category. Period is the first day
+// of the month and Month its "Jan 2006" label.
 type InflationRecord struct {
-	ID       int     `json:"id"`
-	Month    string  `json:"month"`
-	Rate     float64 `json:"rate"`
-	Category string  `json:"category"`
+	ID       int       `json:"id"`
+	Period   time.Time `json:"-"`
+	Month    string    `json:"month"`
+	Rate     float64   `json:"rate"`
+	Category string    `json:"category"`
 }